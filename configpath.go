@@ -0,0 +1,112 @@
+package multiconfig
+
+import (
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// ConfigPathLoader satisifies the loader interface. It loads the config file
+// whose path is given with a flag or an environment variable, i.e: -config
+// or APP_CONFIG. The path is read before the flags are parsed by FlagLoader,
+// so the file can be loaded before the environment variables and flags. The
+// flag takes precedence over the environment variable. The flag must also be
+// defined with FlagLoader.ConfigFlag so it's accepted and shown in the usage.
+type ConfigPathLoader struct {
+	// Flag is the name of the flag, i.e: "config" for -config or --config.
+	Flag string
+
+	// Env is the name of the environment variable, i.e: "APP_CONFIG".
+	Env string
+
+	// Paths are the default config files, loaded in order if neither the flag
+	// nor the environment variable is set.
+	Paths []string
+
+	// Args defines a custom argument list. If nil, os.Args[1:] is used.
+	Args []string
+
+	// FS is the file system to read the config file from, see FileLoader.
+	FS fs.FS
+}
+
+// Load loads the config file into the config defined by struct s.
+func (c *ConfigPathLoader) Load(s interface{}) error {
+	paths := c.Paths
+	if path := c.Path(); path != "" {
+		paths = []string{path}
+	}
+
+	loaders := make([]Loader, 0, len(paths))
+	for _, path := range paths {
+		if path != "" {
+			loaders = append(loaders, &FileLoader{Path: path, FS: c.FS})
+		}
+	}
+
+	return multiLoader(loaders).Load(s)
+}
+
+// SkipMerge implements the MergeSkipper interface. It returns true, as the
+// loaders of the overlays are merged by the MultiLoader it executes.
+func (c *ConfigPathLoader) SkipMerge() bool {
+	return true
+}
+
+// Path returns the path given with the flag or the environment variable. It
+// returns an empty string if neither is set.
+func (c *ConfigPathLoader) Path() string {
+	return flagOrEnv(c.Args, c.Flag, c.Env)
+}
+
+// flagOrEnv returns the value of the flag with the given name in args, or
+// os.Args[1:] if args is nil, or else the value of the environment variable
+// env. Empty names are skipped.
+func flagOrEnv(args []string, name, env string) string {
+	if args == nil {
+		args = filterArgs(os.Args[1:])
+	}
+
+	if name != "" {
+		if value, ok := lookupFlag(args, name); ok {
+			return value
+		}
+	}
+
+	if env != "" {
+		return os.Getenv(env)
+	}
+
+	return ""
+}
+
+// lookupFlag returns the value of the flag with the given name in args. The
+// flag may be given as -name value, -name=value, --name value or
+// --name=value.
+func lookupFlag(args []string, name string) (string, bool) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		arg = strings.TrimPrefix(arg[1:], "-")
+
+		if eq := strings.IndexByte(arg, '='); eq >= 0 {
+			if arg[:eq] == name {
+				return arg[eq+1:], true
+			}
+			continue
+		}
+
+		if arg == name && i+1 < len(args) {
+			return args[i+1], true
+		}
+	}
+
+	return "", false
+}
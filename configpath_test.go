@@ -0,0 +1,76 @@
+package multiconfig
+
+import (
+	"testing"
+)
+
+func TestConfigPathLoader(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  string
+		user string
+	}{
+		{"default", []string{}, "", "ankara"},
+		{"flag", []string{"-config", "testdata/override.yaml"}, "", "gopher"},
+		{"double dash flag", []string{"--config=testdata/override.yaml"}, "", "gopher"},
+		{"env", []string{}, "testdata/override.yaml", "gopher"},
+		{"flag over env", []string{"-port", "1", "-config", testTOML}, "testdata/override.yaml", "ankara"},
+		{"after terminator", []string{"--", "-config", "testdata/override.yaml"}, "", "ankara"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("TEST_CONFIG", test.env)
+
+			c := &ConfigPathLoader{
+				Flag:  "config",
+				Env:   "TEST_CONFIG",
+				Paths: []string{testTOML},
+				Args:  test.args,
+			}
+
+			s := &Server{}
+			if err := c.Load(s); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(s.Users) == 0 || s.Users[0] != test.user {
+				t.Errorf("Users value is wrong: %v, want first: %s", s.Users, test.user)
+			}
+		})
+	}
+}
+
+func TestConfigPathLoaderNotFound(t *testing.T) {
+	c := &ConfigPathLoader{Flag: "config", Args: []string{"-config=testdata/missing.toml"}}
+	if err := c.Load(&Server{}); err == nil {
+		t.Error("error should be returned for a missing config file")
+	}
+}
+
+func TestFlagLoaderConfigFlag(t *testing.T) {
+	f := &FlagLoader{
+		ConfigFlag: "config",
+		ConfigEnv:  "TEST_CONFIG",
+		Args:       []string{"-config", "testdata/override.yaml", "-port", "4000"},
+	}
+
+	s := &Server{}
+	if err := f.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Port != 4000 {
+		t.Errorf("Port value is wrong: %d, want: %d", s.Port, 4000)
+	}
+
+	fl := f.flagSet.Lookup("config")
+	if fl == nil {
+		t.Fatal("config flag should be defined")
+	}
+
+	if want := "Path of the config file, or $TEST_CONFIG."; fl.Usage != want {
+		t.Errorf("Usage is wrong: %q, want: %q", fl.Usage, want)
+	}
+}
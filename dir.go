@@ -0,0 +1,169 @@
+package multiconfig
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DirLoader satisifies the loader interface. It loads the configuration from
+// the files of the given directory, conf.d style. Files are loaded in the
+// lexical order of their names and each one overrides the previous ones, with
+// maps and slices merged as in MultiLoader. The format of each file is chosen
+// in the same way as FileLoader does. Symlinks are followed, subdirectories
+// and hidden files are skipped.
+type DirLoader struct {
+	Path string
+
+	// FS is the file system to read the directory from, i.e: an embed.FS. If
+	// nil, the OS file system is used.
+	FS fs.FS
+
+	// Include are the patterns of the file names to load, i.e: "*.toml". The
+	// syntax is the one of filepath.Match. If empty, all files with the
+	// extension of a registered format are loaded.
+	Include []string
+
+	// Exclude are the patterns of the file names to skip, even if they match
+	// Include.
+	Exclude []string
+
+	// Optional skips the directory if it doesn't exist, instead of returning
+	// ErrFileNotFound.
+	Optional bool
+}
+
+// Load loads the files of the directory into the config defined by struct s.
+// Errors are *ParseErrors naming the file that failed.
+func (d *DirLoader) Load(s interface{}) error {
+	return d.LoadContext(context.Background(), s)
+}
+
+// SkipMerge implements the MergeSkipper interface. It returns true, as the
+// fragments are merged by the MultiLoader it executes.
+func (d *DirLoader) SkipMerge() bool {
+	return true
+}
+
+// LoadContext loads the files of the directory into the config defined by
+// struct s. Loading stops before the next file if ctx is done.
+func (d *DirLoader) LoadContext(ctx context.Context, s interface{}) error {
+	if d.Path == "" {
+		return ErrSourceNotSet
+	}
+
+	files, err := d.files()
+	if err != nil {
+		return err
+	}
+
+	for _, path := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		prev := snapshot(s)
+
+		if err := (&FileLoader{Path: path, FS: d.FS}).Load(s); err != nil {
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				err = &ParseError{Path: path, Err: err}
+			}
+			return err
+		}
+
+		if err := mergeLoaded(prev, s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// files returns the paths of the files to load in lexical order.
+func (d *DirLoader) files() ([]string, error) {
+	fsys := fileSystem{d.FS}
+
+	entries, err := fsys.readDir(d.Path)
+	if os.IsNotExist(err) {
+		if d.Optional {
+			return nil, nil
+		}
+		return nil, ErrFileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		// skip hidden files, i.e: editor swap files
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		ok, err := d.match(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		path := fsys.join(d.Path, name)
+
+		// follow symlinks, i.e: conf-available/app.toml -> conf.d/app.toml
+		info, err := fsys.stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+		}
+	}
+
+	return files, nil
+}
+
+// match reports whether the file with the given name should be loaded.
+func (d *DirLoader) match(name string) (bool, error) {
+	included := len(d.Include) == 0
+	if included {
+		_, included = FormatForPath(name)
+	}
+
+	for _, pattern := range d.Include {
+		ok, err := filepath.Match(pattern, name)
+		if err != nil {
+			return false, err
+		}
+
+		if ok {
+			included = true
+			break
+		}
+	}
+
+	if !included {
+		return false, nil
+	}
+
+	for _, pattern := range d.Exclude {
+		ok, err := filepath.Match(pattern, name)
+		if err != nil {
+			return false, err
+		}
+
+		if ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,127 @@
+package multiconfig
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type DirConfig struct {
+	Name   string
+	Port   int
+	Users  []string `merge:"append"`
+	Labels map[string]string
+}
+
+func TestDirLoader(t *testing.T) {
+	s := &DirConfig{}
+	if err := (&DirLoader{Path: testDir}).Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Name != "koding" {
+		t.Errorf("Name value is wrong: %s, want: %s", s.Name, "koding")
+	}
+
+	if s.Port != 7070 {
+		t.Errorf("Port value is wrong: %d, want: %d", s.Port, 7070)
+	}
+
+	if want := []string{"ankara", "istanbul"}; !reflect.DeepEqual(s.Users, want) {
+		t.Errorf("Users value is wrong: %v, want: %v", s.Users, want)
+	}
+
+	if want := map[string]string{"env": "dev", "team": "core"}; !reflect.DeepEqual(s.Labels, want) {
+		t.Errorf("Labels value is wrong: %v, want: %v", s.Labels, want)
+	}
+}
+
+func TestDirLoaderIncludeExclude(t *testing.T) {
+	s := &DirConfig{}
+	d := &DirLoader{
+		Path:    testDir,
+		Include: []string{"*.toml", "*.json"},
+		Exclude: []string{"30-*"},
+	}
+
+	if err := d.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Port != 6060 {
+		t.Errorf("Port value is wrong: %d, want: %d", s.Port, 6060)
+	}
+
+	if want := []string{"ankara"}; !reflect.DeepEqual(s.Users, want) {
+		t.Errorf("Users value is wrong: %v, want: %v", s.Users, want)
+	}
+}
+
+func TestDirLoaderError(t *testing.T) {
+	d := &DirLoader{Path: testDir, Include: []string{"*.conf"}}
+
+	var perr *ParseError
+	if err := d.Load(&DirConfig{}); !errors.As(err, &perr) {
+		t.Fatalf("error should be a *ParseError, got: %v", err)
+	}
+
+	if want := filepath.Join(testDir, "40-broken.conf"); perr.Path != want {
+		t.Errorf("Path is wrong: %s, want: %s", perr.Path, want)
+	}
+}
+
+func TestDirLoaderNotFound(t *testing.T) {
+	d := &DirLoader{Path: "testdata/missing.d"}
+	if err := d.Load(&DirConfig{}); err != ErrFileNotFound {
+		t.Errorf("error should be ErrFileNotFound, got: %v", err)
+	}
+
+	d.Optional = true
+	if err := d.Load(&DirConfig{}); err != nil {
+		t.Errorf("optional directory should be skipped, got: %v", err)
+	}
+}
+
+func TestDirLoaderSymlinks(t *testing.T) {
+	dir := t.TempDir()
+
+	available := filepath.Join(dir, "conf-available")
+	confd := filepath.Join(dir, "conf.d")
+
+	for _, d := range []string{available, confd, filepath.Join(confd, "30-dir.toml")} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := map[string]string{
+		filepath.Join(available, "10-name.toml"): `name = "koding"`,
+		filepath.Join(confd, ".20-port.toml"):    `port = 1111`,
+	}
+
+	for path, content := range files {
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.Symlink(filepath.Join("..", "conf-available", "10-name.toml"), filepath.Join(confd, "10-name.toml")); err != nil {
+		t.Skip("symlinks aren't supported:", err)
+	}
+
+	s := &DirConfig{}
+	if err := (&DirLoader{Path: confd, Include: []string{"*"}}).Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Name != "koding" {
+		t.Errorf("Name value is wrong: %s, want: %s", s.Name, "koding")
+	}
+
+	if s.Port != 0 {
+		t.Errorf("hidden file should be skipped, Port value: %d", s.Port)
+	}
+}
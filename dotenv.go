@@ -0,0 +1,334 @@
+package multiconfig
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// DotEnvLoader satisifies the loader interface. It loads the configuration
+// from the given .env file or Reader. The variables are named in the same
+// way as the ones of EnvironmentLoader, i.e: STRUCTNAME_FIELDNAME. The
+// process environment is not modified.
+//
+// The file consists of KEY=VALUE lines, optionally prefixed with "export".
+// Lines starting with # are comments. Values may be quoted, single quoted
+// values are taken literally, double quoted values may span multiple lines
+// and support escape sequences. ${VAR} and $VAR in unquoted and double quoted
+// values are expanded with the variables defined before, or with the process
+// environment.
+type DotEnvLoader struct {
+	Path   string
+	Reader io.Reader
+	FS     fs.FS
+
+	// Overlays are the paths of files that are loaded after Path or Reader,
+	// each one overriding the variables of the previous ones, i.e:
+	// ".env.local". Missing overlay files are skipped.
+	Overlays []string
+
+	// Prefix prepends given string to every variable, see
+	// EnvironmentLoader.Prefix.
+	Prefix string
+
+	// CamelCase adds a separator for field names in camelcase form, see
+	// EnvironmentLoader.CamelCase.
+	CamelCase bool
+}
+
+// Load loads the source into the config defined by struct s.
+// Defaults to using the Reader if provided, otherwise tries to read from the
+// file
+func (d *DotEnvLoader) Load(s interface{}) error {
+	vars := make(map[string]string)
+
+	if d.Reader != nil {
+		if err := parseDotEnv(d.Path, d.Reader, vars); err != nil {
+			return err
+		}
+	} else if d.Path != "" {
+		if err := d.parseFile(d.Path, vars); err != nil {
+			return err
+		}
+	} else if len(d.Overlays) == 0 {
+		return ErrSourceNotSet
+	}
+
+	for _, path := range d.Overlays {
+		err := d.parseFile(path, vars)
+		if err != nil && err != ErrFileNotFound {
+			return err
+		}
+	}
+
+	e := &EnvironmentLoader{
+		Prefix:    d.Prefix,
+		CamelCase: d.CamelCase,
+		getenv:    func(key string) string { return vars[key] },
+	}
+
+	return e.Load(s)
+}
+
+func (d *DotEnvLoader) parseFile(path string, vars map[string]string) error {
+	file, _, err := fileSystem{d.FS}.open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return parseDotEnv(path, file, vars)
+}
+
+// parseDotEnv parses the .env content of r into vars, path is only used for
+// errors.
+func parseDotEnv(path string, r io.Reader, vars map[string]string) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	p := &dotEnvParser{
+		src:  string(data),
+		line: 1,
+		path: path,
+		vars: vars,
+	}
+
+	return p.parse()
+}
+
+type dotEnvParser struct {
+	src  string
+	pos  int
+	line int
+	path string
+	vars map[string]string
+}
+
+func (p *dotEnvParser) parse() error {
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.src) {
+			return nil
+		}
+
+		if p.src[p.pos] == '#' {
+			p.skipLine()
+			continue
+		}
+
+		key, err := p.parseKey()
+		if err != nil {
+			return err
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return err
+		}
+
+		p.vars[key] = value
+	}
+}
+
+func (p *dotEnvParser) errorf(line int, format string, args ...interface{}) error {
+	return &ParseError{Path: p.path, Line: line, Err: fmt.Errorf(format, args...)}
+}
+
+// skipBlank skips whitespace including newlines.
+func (p *dotEnvParser) skipBlank() {
+	for ; p.pos < len(p.src) && strings.IndexByte(" \t\r\n", p.src[p.pos]) >= 0; p.pos++ {
+		if p.src[p.pos] == '\n' {
+			p.line++
+		}
+	}
+}
+
+// skipLine skips to the end of the current line.
+func (p *dotEnvParser) skipLine() {
+	for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+		p.pos++
+	}
+}
+
+func (p *dotEnvParser) parseKey() (string, error) {
+	rest := p.src[p.pos:]
+	if i := strings.IndexByte(rest, '\n'); i >= 0 {
+		rest = rest[:i]
+	}
+
+	i := strings.IndexByte(rest, '=')
+	if i < 0 {
+		return "", p.errorf(p.line, "expected KEY=VALUE")
+	}
+
+	key := strings.TrimSpace(rest[:i])
+	if strings.HasPrefix(key, "export ") || strings.HasPrefix(key, "export\t") {
+		key = strings.TrimSpace(key[len("export "):])
+	}
+
+	if key == "" || strings.IndexFunc(key, func(r rune) bool { return !isVarChar(r) && r != '.' }) >= 0 {
+		return "", p.errorf(p.line, "invalid key %q", key)
+	}
+
+	p.pos += i + 1
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+
+	return key, nil
+}
+
+func (p *dotEnvParser) parseValue() (string, error) {
+	if p.pos >= len(p.src) {
+		return "", nil
+	}
+
+	switch p.src[p.pos] {
+	case '"', '\'':
+		return p.parseQuoted()
+	}
+
+	raw := p.src[p.pos:]
+	if i := strings.IndexByte(raw, '\n'); i >= 0 {
+		raw = raw[:i]
+	}
+	p.pos += len(raw)
+
+	// a # preceded by whitespace starts a comment
+	if i := strings.Index(raw, " #"); i >= 0 {
+		raw = raw[:i]
+	}
+	if i := strings.Index(raw, "\t#"); i >= 0 {
+		raw = raw[:i]
+	}
+
+	return p.expand(strings.TrimSpace(raw)), nil
+}
+
+// parseQuoted parses a single or double quoted value starting at the current
+// position. Double quoted values are expanded while parsing so that escaped
+// dollar signs are kept.
+func (p *dotEnvParser) parseQuoted() (string, error) {
+	quote := p.src[p.pos]
+	line := p.line
+	p.pos++
+
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+
+		switch {
+		case c == quote:
+			p.pos++
+
+			// only a comment may follow the closing quote
+			rest := p.src[p.pos:]
+			if i := strings.IndexByte(rest, '\n'); i >= 0 {
+				rest = rest[:i]
+			}
+			if rest = strings.TrimSpace(rest); rest != "" && rest[0] != '#' {
+				return "", p.errorf(p.line, "unexpected %q after quoted value", rest)
+			}
+			p.skipLine()
+
+			return b.String(), nil
+		case c == '\n':
+			p.line++
+		case quote == '"' && c == '\\' && p.pos+1 < len(p.src):
+			p.pos++
+			switch e := p.src[p.pos]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\', '$':
+				b.WriteByte(e)
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(e)
+			}
+			p.pos++
+			continue
+		case quote == '"' && c == '$':
+			if name, n := varRef(p.src[p.pos:]); n > 0 {
+				b.WriteString(p.lookup(name))
+				p.pos += n
+				continue
+			}
+		}
+
+		b.WriteByte(c)
+		p.pos++
+	}
+
+	return "", p.errorf(line, "unterminated quoted value")
+}
+
+// expand replaces ${VAR} and $VAR references in s.
+func (p *dotEnvParser) expand(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if s[i] == '$' {
+			if name, n := varRef(s[i:]); n > 0 {
+				b.WriteString(p.lookup(name))
+				i += n
+				continue
+			}
+		}
+
+		b.WriteByte(s[i])
+		i++
+	}
+
+	return b.String()
+}
+
+// lookup returns the value of a variable defined before, or of the process
+// environment.
+func (p *dotEnvParser) lookup(name string) string {
+	if v, ok := p.vars[name]; ok {
+		return v
+	}
+
+	return os.Getenv(name)
+}
+
+// varRef parses the ${VAR} or $VAR reference at the start of s. It returns
+// the variable name and the length of the reference, or zero if s doesn't
+// start with a reference.
+func varRef(s string) (string, int) {
+	if len(s) < 2 || s[0] != '$' {
+		return "", 0
+	}
+
+	if s[1] == '{' {
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			return "", 0
+		}
+
+		return s[2:end], end + 1
+	}
+
+	n := 1
+	for n < len(s) && isVarChar(rune(s[n])) {
+		n++
+	}
+
+	if n == 1 {
+		return "", 0
+	}
+
+	return s[1:n], n
+}
+
+func isVarChar(r rune) bool {
+	return r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
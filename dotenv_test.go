@@ -0,0 +1,137 @@
+package multiconfig
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDotEnv(t *testing.T) {
+	l := MultiLoader(&TagLoader{}, &DotEnvLoader{Path: testEnv})
+
+	s := &Server{}
+	if err := l.Load(s); err != nil {
+		t.Error(err)
+	}
+
+	testStruct(t, s, getDefaultServer())
+}
+
+func TestDotEnvOverlays(t *testing.T) {
+	l := &DotEnvLoader{
+		Path:     testEnv,
+		Overlays: []string{testEnv + ".local", testEnv + ".missing"},
+	}
+
+	s := &Server{}
+	if err := l.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Name != "koding" {
+		t.Errorf("Name value is wrong: %s, want: %s", s.Name, "koding")
+	}
+
+	if s.Port != 7070 {
+		t.Errorf("Port value is wrong: %d, want: %d", s.Port, 7070)
+	}
+}
+
+func TestDotEnvPrefixCamelCase(t *testing.T) {
+	l := &DotEnvLoader{
+		Reader:    strings.NewReader("APP_ACCESS_KEY=123456\nAPP_DB_NAME=configdb\n"),
+		Prefix:    "app",
+		CamelCase: true,
+	}
+
+	s := &CamelCaseServer{}
+	if err := l.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.AccessKey != "123456" {
+		t.Errorf("AccessKey is wrong: %s, want: %s", s.AccessKey, "123456")
+	}
+
+	if s.DBName != "configdb" {
+		t.Errorf("DBName is wrong: %s, want: %s", s.DBName, "configdb")
+	}
+}
+
+func TestParseDotEnv(t *testing.T) {
+	os.Setenv("MULTICONFIG_TEST_HOME", "/home/gopher")
+	defer os.Unsetenv("MULTICONFIG_TEST_HOME")
+
+	src := `# comment
+PLAIN=value
+export EXPORTED = exported
+EMPTY=
+COMMENTED=value # comment
+HASH=value#hash
+SINGLE='${PLAIN} is literal'
+DOUBLE="${PLAIN}\tescaped \"quotes\" \$PLAIN"
+MULTI="first
+second"
+EXPANDED=$PLAIN-${MULTICONFIG_TEST_HOME}/data
+UNDEFINED=${MULTICONFIG_TEST_UNDEFINED}
+`
+
+	want := map[string]string{
+		"PLAIN":     "value",
+		"EXPORTED":  "exported",
+		"EMPTY":     "",
+		"COMMENTED": "value",
+		"HASH":      "value#hash",
+		"SINGLE":    "${PLAIN} is literal",
+		"DOUBLE":    "value\tescaped \"quotes\" $PLAIN",
+		"MULTI":     "first\nsecond",
+		"EXPANDED":  "value-/home/gopher/data",
+		"UNDEFINED": "",
+	}
+
+	vars := make(map[string]string)
+	if err := parseDotEnv("", strings.NewReader(src), vars); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(vars) != len(want) {
+		t.Errorf("vars are wrong: %q, want: %q", vars, want)
+	}
+
+	for key, val := range want {
+		if vars[key] != val {
+			t.Errorf("%s is wrong: %q, want: %q", key, vars[key], val)
+		}
+	}
+
+	if v := os.Getenv("PLAIN"); v != "" {
+		t.Errorf("process environment should not be modified, PLAIN: %s", v)
+	}
+}
+
+func TestParseDotEnvErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		line int
+	}{
+		{src: "FOO=bar\nBAR\n", line: 2},
+		{src: "FOO=bar\n\nBAR BAZ=qux\n", line: 3},
+		{src: "FOO=bar\nBAR=\"unterminated\n\n", line: 2},
+		{src: "FOO='bar' baz\n", line: 1},
+	}
+
+	for _, test := range tests {
+		err := parseDotEnv("", strings.NewReader(test.src), make(map[string]string))
+
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%q: error should be a *ParseError, got: %v", test.src, err)
+			continue
+		}
+
+		if perr.Line != test.line {
+			t.Errorf("%q: Line is wrong: %d, want: %d", test.src, perr.Line, test.line)
+		}
+	}
+}
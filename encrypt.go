@@ -0,0 +1,176 @@
+package multiconfig
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+)
+
+var (
+	// ErrKeyNotSet states that neither the environment variable or the file
+	// of an EncryptionKey is set.
+	ErrKeyNotSet = errors.New("encryption key is not set")
+
+	// ErrDecrypt states that an encrypted value or file can't be decrypted,
+	// because it's malformed or encrypted with another key.
+	ErrDecrypt = errors.New("cannot decrypt value")
+)
+
+const (
+	encPrefix = "ENC["
+	encSuffix = "]"
+)
+
+// EncryptionKey is the key of the encrypted config files and values. Values
+// are encrypted with AES-256-GCM by EncryptValue, in the form of
+// "ENC[<base64>]":
+//
+//	password = "ENC[2x0V3k...]"
+//
+// A whole file is encrypted as a single value, its content being the
+// encrypted value. Encrypted values are decrypted after decoding the file, so
+// only string values can be encrypted. The key is 32 random bytes encoded in
+// base64, as returned by GenerateKey.
+type EncryptionKey struct {
+	// Env is the name of the environment variable with the key, i.e:
+	// "APP_KEY". It takes precedence over File.
+	Env string
+
+	// File is the path of the file with the key.
+	File string
+}
+
+// GenerateKey returns a new random key, encoded in base64.
+func GenerateKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// EncryptValue encrypts value with the given base64 encoded key. The result,
+// in the form of "ENC[<base64>]", can be used as a value of a config file, or
+// as the content of an encrypted file.
+func EncryptValue(key, value string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	data := aead.Seal(nonce, nonce, []byte(value), nil)
+	return encPrefix + base64.StdEncoding.EncodeToString(data) + encSuffix, nil
+}
+
+// key returns the base64 encoded key.
+func (k *EncryptionKey) key() (string, error) {
+	if k.Env != "" {
+		if key := os.Getenv(k.Env); key != "" {
+			return key, nil
+		}
+	}
+
+	if k.File == "" {
+		return "", ErrKeyNotSet
+	}
+
+	data, err := ioutil.ReadFile(k.File)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// decryptFile returns the decrypted content of data if the whole file is
+// encrypted, otherwise data as is.
+func (k *EncryptionKey) decryptFile(data []byte) ([]byte, error) {
+	value := strings.TrimSpace(string(data))
+	if !isEncrypted(value) {
+		return data, nil
+	}
+
+	plain, err := k.decrypt(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(plain), nil
+}
+
+// decryptValues decrypts the encrypted strings of the config defined by
+// struct s. The returned error is a *FieldError.
+func (k *EncryptionKey) decryptValues(s interface{}) error {
+	return interpolate(reflect.ValueOf(s).Elem(), "", func(v string) (string, error) {
+		if !isEncrypted(v) {
+			return v, nil
+		}
+
+		return k.decrypt(v)
+	})
+}
+
+// decrypt decrypts the value in the form of "ENC[<base64>]".
+func (k *EncryptionKey) decrypt(value string) (string, error) {
+	key, err := k.key()
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(value[len(encPrefix) : len(value)-len(encSuffix)])
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", ErrDecrypt
+	}
+
+	nonce, data := data[:aead.NonceSize()], data[aead.NonceSize():]
+
+	plain, err := aead.Open(nil, nonce, data, nil)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+
+	return string(plain), nil
+}
+
+// isEncrypted reports whether value is in the form of "ENC[<base64>]".
+func isEncrypted(value string) bool {
+	return strings.HasPrefix(value, encPrefix) && strings.HasSuffix(value, encSuffix) &&
+		!strings.ContainsAny(value, " \t\r\n")
+}
+
+// newAEAD returns the AES-256-GCM cipher of the given base64 encoded key.
+func newAEAD(key string) (cipher.AEAD, error) {
+	k, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil {
+		return nil, fmt.Errorf("multiconfig: invalid encryption key: %w", err)
+	}
+
+	if len(k) != 32 {
+		return nil, fmt.Errorf("multiconfig: invalid encryption key: %d bytes, want 32", len(k))
+	}
+
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,165 @@
+package multiconfig
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type EncryptedConfig struct {
+	Name     string
+	Password string
+	Tokens   map[string]string
+}
+
+func encrypt(t *testing.T, key, value string) string {
+	t.Helper()
+
+	enc, err := EncryptValue(key, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return enc
+}
+
+func TestEncryptedValues(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("ENCRYPTED_KEY", key)
+
+	password := encrypt(t, key, "hunter2")
+	token := encrypt(t, key, "s3cr3t")
+
+	if !strings.HasPrefix(password, "ENC[") || strings.Contains(password, "hunter2") {
+		t.Fatalf("encrypted value is wrong: %s", password)
+	}
+
+	if other := encrypt(t, key, "hunter2"); other == password {
+		t.Errorf("encrypted values should differ for each encryption: %s", other)
+	}
+
+	tests := []struct {
+		name   string
+		loader func(data string, key *EncryptionKey) Loader
+		data   string
+	}{
+		{
+			name: "toml",
+			loader: func(data string, key *EncryptionKey) Loader {
+				return &TOMLLoader{Reader: strings.NewReader(data), Key: key}
+			},
+			data: "name = \"koding\"\npassword = \"" + password + "\"\n[tokens]\napi = \"" + token + "\"\n",
+		},
+		{
+			name: "json",
+			loader: func(data string, key *EncryptionKey) Loader {
+				return &JSONLoader{Reader: strings.NewReader(data), Key: key}
+			},
+			data: `{"name": "koding", "password": "` + password + `", "tokens": {"api": "` + token + `"}}`,
+		},
+		{
+			name: "yaml",
+			loader: func(data string, key *EncryptionKey) Loader {
+				return &YAMLLoader{Reader: strings.NewReader(data), Key: key}
+			},
+			data: "name: koding\npassword: " + password + "\ntokens:\n  api: " + token + "\n",
+		},
+		{
+			name: "file",
+			loader: func(data string, key *EncryptionKey) Loader {
+				return &FileLoader{Reader: strings.NewReader(data), Key: key}
+			},
+			data: "name = \"koding\"\npassword = \"" + password + "\"\n[tokens]\napi = \"" + token + "\"\n",
+		},
+	}
+
+	for _, test := range tests {
+		s := &EncryptedConfig{}
+		if err := test.loader(test.data, &EncryptionKey{Env: "ENCRYPTED_KEY"}).Load(s); err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		if s.Name != "koding" {
+			t.Errorf("%s: Name value is wrong: %s, want: %s", test.name, s.Name, "koding")
+		}
+
+		if s.Password != "hunter2" {
+			t.Errorf("%s: Password value is wrong: %s, want: %s", test.name, s.Password, "hunter2")
+		}
+
+		if s.Tokens["api"] != "s3cr3t" {
+			t.Errorf("%s: Tokens value is wrong: %v", test.name, s.Tokens)
+		}
+	}
+}
+
+func TestEncryptedFile(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+
+	keyFile := filepath.Join(dir, "key")
+	if err := ioutil.WriteFile(keyFile, []byte(key+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "config.json")
+	content := encrypt(t, key, `{"name": "koding", "password": "hunter2"}`)
+	if err := ioutil.WriteFile(path, []byte(content+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &EncryptedConfig{}
+	if err := (&FileLoader{Path: path, Key: &EncryptionKey{File: keyFile}}).Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Name != "koding" || s.Password != "hunter2" {
+		t.Errorf("config is wrong: %+v", s)
+	}
+}
+
+func TestEncryptedValuesError(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("ENCRYPTED_KEY", other)
+
+	data := `password = "` + encrypt(t, key, "hunter2") + `"`
+
+	err = (&TOMLLoader{Reader: strings.NewReader(data), Key: &EncryptionKey{Env: "ENCRYPTED_KEY"}}).Load(&EncryptedConfig{})
+	if !errors.Is(err, ErrDecrypt) {
+		t.Errorf("error should be ErrDecrypt, got: %v", err)
+	}
+
+	var ferr *FieldError
+	if !errors.As(err, &ferr) || ferr.Field != "Password" {
+		t.Errorf("error should be a *FieldError for Password, got: %v", err)
+	}
+
+	err = (&TOMLLoader{Reader: strings.NewReader(data), Key: &EncryptionKey{Env: "ENCRYPTED_MISSING"}}).Load(&EncryptedConfig{})
+	if !errors.Is(err, ErrKeyNotSet) {
+		t.Errorf("error should be ErrKeyNotSet, got: %v", err)
+	}
+
+	if _, err := EncryptValue("c2hvcnQ=", "hunter2"); err == nil {
+		t.Error("error should be returned for a short key")
+	}
+}
@@ -2,6 +2,7 @@ package multiconfig
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"sort"
 	"strings"
@@ -10,6 +11,10 @@ import (
 	"github.com/fatih/structs"
 )
 
+// fileSuffix is the suffix of the variables naming the file to load a value
+// from, see EnvironmentLoader.FileIndirection.
+const fileSuffix = "_FILE"
+
 // EnvironmentLoader satisifies the loader interface. It loads the
 // configuration from the environment variables in the form of
 // STRUCTNAME_FIELDNAME.
@@ -23,6 +28,18 @@ type EnvironmentLoader struct {
 	// "STRUCTNAME_ACCESSKEY". If CamelCase is enabled, the environment name
 	// will be generated in the form of "STRUCTNAME_ACCESS_KEY"
 	CamelCase bool
+
+	// FileIndirection loads the value of a field from the file named by the
+	// variable with the "_FILE" suffix, i.e: the file in
+	// SERVER_DBPASSWORD_FILE for SERVER_DBPASSWORD, as Docker secrets are
+	// passed. Setting both variables returns a *FieldError wrapping
+	// ErrEnvFileConflict. PrintEnvs lists the "_FILE" variables of the fields
+	// tagged with `secret:"true"`.
+	FileIndirection bool
+
+	// getenv retrieves the value of the given variable. If nil, os.Getenv is
+	// used.
+	getenv func(key string) string
 }
 
 func (e *EnvironmentLoader) getPrefix(s *structs.Struct) string {
@@ -42,7 +59,7 @@ func (e *EnvironmentLoader) Load(s interface{}) error {
 	for key, val := range strctMap {
 		field := strct.Field(key)
 
-		if err := e.processField(prefix, field, key, val); err != nil {
+		if err := e.processField(prefix, "", field, key, val); err != nil {
 			return err
 		}
 	}
@@ -51,26 +68,32 @@ func (e *EnvironmentLoader) Load(s interface{}) error {
 }
 
 // processField gets leading name for the env variable and combines the current
-// field's name and generates environment variable names recursively
-func (e *EnvironmentLoader) processField(prefix string, field *structs.Field, name string, strctMap interface{}) error {
+// field's name and generates environment variable names recursively. path is
+// the path of the struct of the field.
+func (e *EnvironmentLoader) processField(prefix, path string, field *structs.Field, name string, strctMap interface{}) error {
 	fieldName := e.generateFieldName(prefix, name)
+	path = fieldPath(path, field.Name())
 
 	switch strctMap.(type) {
 	case map[string]interface{}:
 		for key, val := range strctMap.(map[string]interface{}) {
 			field := field.Field(key)
 
-			if err := e.processField(fieldName, field, key, val); err != nil {
+			if err := e.processField(fieldName, path, field, key, val); err != nil {
 				return err
 			}
 		}
 	default:
-		v := os.Getenv(fieldName)
+		v, err := e.value(fieldName)
+		if err != nil {
+			return &FieldError{Field: path, Err: err}
+		}
+
 		if v == "" {
 			return nil
 		}
 
-		if err := fieldSet(field, v); err != nil {
+		if err := fieldSet(field, path, v); err != nil {
 			return err
 		}
 	}
@@ -78,6 +101,41 @@ func (e *EnvironmentLoader) processField(prefix string, field *structs.Field, na
 	return nil
 }
 
+// lookup returns the value of the given environment variable.
+func (e *EnvironmentLoader) lookup(key string) string {
+	if e.getenv != nil {
+		return e.getenv(key)
+	}
+
+	return os.Getenv(key)
+}
+
+// value returns the value of the given environment variable, or the content
+// of the file named by its "_FILE" variant. It returns an error wrapping
+// ErrEnvFileConflict if both are set.
+func (e *EnvironmentLoader) value(key string) (string, error) {
+	v := e.lookup(key)
+	if !e.FileIndirection {
+		return v, nil
+	}
+
+	path := e.lookup(key + fileSuffix)
+	if path == "" {
+		return v, nil
+	}
+
+	if v != "" {
+		return "", fmt.Errorf("%w: %s, %s%s", ErrEnvFileConflict, key, key, fileSuffix)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("%s%s: %w", key, fileSuffix, err)
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
 // PrintEnvs prints the generated environment variables to the std out.
 func (e *EnvironmentLoader) PrintEnvs(s interface{}) {
 	strct := structs.New(s)
@@ -114,6 +172,9 @@ func (e *EnvironmentLoader) printField(prefix string, field *structs.Field, name
 		}
 	default:
 		fmt.Println("  ", fieldName)
+		if e.FileIndirection && field.Tag("secret") == "true" {
+			fmt.Println("  ", fieldName+fileSuffix)
+		}
 	}
 }
 
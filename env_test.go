@@ -1,7 +1,10 @@
 package multiconfig
 
 import (
+	"errors"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -148,3 +151,60 @@ func TestENVgetPrefix(t *testing.T) {
 		t.Errorf("Prefix is wrong: %s, want: %s", p, prefix)
 	}
 }
+
+type FileEnvConfig struct {
+	Name     string
+	Password string `secret:"true"`
+}
+
+func TestENVFileIndirection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "password")
+	if err := ioutil.WriteFile(path, []byte("s3cr3t\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("FILEENVCONFIG_NAME", "koding")
+	t.Setenv("FILEENVCONFIG_PASSWORD_FILE", path)
+
+	s := &FileEnvConfig{}
+	if err := (&EnvironmentLoader{}).Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Password != "" {
+		t.Errorf("Password should not be loaded without FileIndirection, got: %q", s.Password)
+	}
+
+	if err := (&EnvironmentLoader{FileIndirection: true}).Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Name != "koding" {
+		t.Errorf("Name value is wrong: %q, want: %q", s.Name, "koding")
+	}
+
+	if s.Password != "s3cr3t" {
+		t.Errorf("Password value is wrong: %q, want: %q", s.Password, "s3cr3t")
+	}
+
+	t.Setenv("FILEENVCONFIG_PASSWORD", "other")
+
+	err := (&EnvironmentLoader{FileIndirection: true}).Load(&FileEnvConfig{})
+	if !errors.Is(err, ErrEnvFileConflict) || !strings.Contains(err.Error(), "FILEENVCONFIG_PASSWORD_FILE") {
+		t.Errorf("error should be ErrEnvFileConflict naming both variables, got: %v", err)
+	}
+
+	var ferr *FieldError
+	if !errors.As(err, &ferr) || ferr.Field != "Password" {
+		t.Errorf("error should be a *FieldError for Password, got: %v", err)
+	}
+}
+
+func TestENVFileIndirectionNotFound(t *testing.T) {
+	t.Setenv("FILEENVCONFIG_PASSWORD_FILE", "testdata/missing")
+
+	err := (&EnvironmentLoader{FileIndirection: true}).Load(&FileEnvConfig{})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("error should wrap a not exist error, got: %v", err)
+	}
+}
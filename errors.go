@@ -0,0 +1,148 @@
+package multiconfig
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrUnsupportedType states that a field's type can't be set from a string
+// value.
+var ErrUnsupportedType = errors.New("unsupported type")
+
+// ErrEnvFileConflict states that both an environment variable and its
+// "_FILE" variant are set.
+var ErrEnvFileConflict = errors.New("both the environment variable and its _FILE variant are set")
+
+// ErrIncludeCycle states that a config file includes itself, directly or
+// through other files.
+var ErrIncludeCycle = errors.New("include cycle")
+
+// FieldError is returned when a value can't be assigned to a field of the
+// config struct, i.e: an environment variable or a flag that can't be
+// converted to the type of the field.
+type FieldError struct {
+	// Field is the path of the field. The fields of nested structs are
+	// separated by dots, i.e: Postgres.Port.
+	Field string
+
+	// Value is the raw value that was tried to be set, if any.
+	Value string
+
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *FieldError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("multiconfig: cannot set field '%s': %s", e.Field, e.Err)
+	}
+
+	return fmt.Sprintf("multiconfig: cannot set field '%s' to %q: %s", e.Field, e.Value, e.Err)
+}
+
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// ParseError is returned when a configuration source can't be parsed, i.e: a
+// file with a syntax error or with values that don't match the struct.
+type ParseError struct {
+	// Path is the path of the file that failed to parse. It's empty if the
+	// source was an io.Reader.
+	Path string
+
+	// Line is the line number the error was found at. Zero if unknown.
+	Line int
+
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	source := e.Path
+	if source == "" {
+		source = "config"
+	}
+
+	if e.Line > 0 {
+		source = fmt.Sprintf("%s:%d", source, e.Line)
+	}
+
+	return fmt.Sprintf("multiconfig: cannot parse %s: %s", source, e.Err)
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// RequiredError is returned by the RequiredValidator for a required field
+// that has a zero value.
+type RequiredError struct {
+	// Field is the name of the field. Fields of nested structs are separated
+	// by dots, i.e: "Postgres.Port".
+	Field string
+}
+
+func (e *RequiredError) Error() string {
+	return fmt.Sprintf("multiconfig: field '%s' is required", e.Field)
+}
+
+// UnknownKeyError is returned by loaders that disallow unknown keys when the
+// source contains a key that doesn't match any field of the struct.
+type UnknownKeyError struct {
+	// Key is the key as it's written in the source. Keys of nested tables or
+	// objects are separated by dots, i.e: "postgres.foo".
+	Key string
+}
+
+func (e *UnknownKeyError) Error() string {
+	return fmt.Sprintf("multiconfig: unknown key '%s'", e.Key)
+}
+
+// LoaderError is returned by MultiLoader and names the loader that failed.
+type LoaderError struct {
+	// Loader is the type name of the failed loader, i.e: "TOMLLoader".
+	Loader string
+
+	// Err is the error returned by the loader.
+	Err error
+}
+
+func (e *LoaderError) Error() string {
+	// errors of this package are already prefixed, don't repeat it
+	msg := strings.TrimPrefix(e.Err.Error(), "multiconfig: ")
+	return fmt.Sprintf("multiconfig: %s: %s", e.Loader, msg)
+}
+
+func (e *LoaderError) Unwrap() error { return e.Err }
+
+// IncludeError is returned by FileLoader when an included file can't be
+// loaded, it names the chain of includes leading to the file that failed.
+type IncludeError struct {
+	// Chain are the paths of the files, from the loaded file to the included
+	// file that failed.
+	Chain []string
+
+	// Err is the underlying error, ErrIncludeCycle for a file that includes
+	// itself.
+	Err error
+}
+
+func (e *IncludeError) Error() string {
+	msg := strings.TrimPrefix(e.Err.Error(), "multiconfig: ")
+	return fmt.Sprintf("multiconfig: include %s: %s", strings.Join(e.Chain, " -> "), msg)
+}
+
+func (e *IncludeError) Unwrap() error { return e.Err }
+
+// loaderName returns the type name of the given loader, used for LoaderError.
+func loaderName(l Loader) string {
+	t := reflect.TypeOf(l)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Name() == "" {
+		return fmt.Sprintf("%T", l)
+	}
+
+	return t.Name()
+}
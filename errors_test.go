@@ -0,0 +1,192 @@
+package multiconfig
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFieldError(t *testing.T) {
+	s := &struct {
+		Port int `default:"foo"`
+	}{}
+
+	err := (&TagLoader{}).Load(s)
+
+	var ferr *FieldError
+	if !errors.As(err, &ferr) {
+		t.Fatalf("error should be a *FieldError, got: %v", err)
+	}
+
+	if ferr.Field != "Port" {
+		t.Errorf("Field is wrong: %s, want: %s", ferr.Field, "Port")
+	}
+
+	if ferr.Value != "foo" {
+		t.Errorf("Value is wrong: %s, want: %s", ferr.Value, "foo")
+	}
+}
+
+func TestFieldErrorPath(t *testing.T) {
+	type Config struct {
+		Postgres struct {
+			Port int `default:"foo"`
+		}
+	}
+
+	t.Setenv("FIELDPATH_POSTGRES_PORT", "foo")
+
+	tests := []struct {
+		name   string
+		loader Loader
+	}{
+		{name: "tag", loader: &TagLoader{}},
+		{name: "env", loader: &EnvironmentLoader{Prefix: "FIELDPATH"}},
+		{name: "ini", loader: &INILoader{Reader: strings.NewReader("[postgres]\nport = foo\n")}},
+		{name: "properties", loader: &PropertiesLoader{Reader: strings.NewReader("postgres.port = foo\n")}},
+		{name: "xml", loader: &XMLLoader{Reader: strings.NewReader("<config><postgres><port>foo</port></postgres></config>")}},
+	}
+
+	for _, test := range tests {
+		err := test.loader.Load(&Config{})
+
+		var ferr *FieldError
+		if !errors.As(err, &ferr) {
+			t.Errorf("%s: error should be a *FieldError, got: %v", test.name, err)
+			continue
+		}
+
+		if ferr.Field != "Postgres.Port" {
+			t.Errorf("%s: Field is wrong: %s, want: %s", test.name, ferr.Field, "Postgres.Port")
+		}
+	}
+
+	err := (&FlagLoader{Args: []string{"-postgres-port", "foo"}}).Load(&Config{})
+	if err == nil || !strings.Contains(err.Error(), "'Postgres.Port'") {
+		t.Errorf("flag: error should name Postgres.Port, got: %v", err)
+	}
+}
+
+func TestFieldErrorUnsupportedType(t *testing.T) {
+	s := &struct {
+		Ratios []float64 `default:"1.2,3.4"`
+	}{}
+
+	err := (&TagLoader{}).Load(s)
+	if !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("error should be ErrUnsupportedType, got: %v", err)
+	}
+}
+
+func TestRequiredError(t *testing.T) {
+	s := getDefaultServer()
+	s.Postgres.Port = 0
+
+	err := (&RequiredValidator{}).Validate(s)
+
+	var rerr *RequiredError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("error should be a *RequiredError, got: %v", err)
+	}
+
+	if rerr.Field != "Postgres.Port" {
+		t.Errorf("Field is wrong: %s, want: %s", rerr.Field, "Postgres.Port")
+	}
+}
+
+func TestParseError(t *testing.T) {
+	l := &JSONLoader{Reader: strings.NewReader(`{"Name": `)}
+
+	err := l.Load(&Server{})
+
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("error should be a *ParseError, got: %v", err)
+	}
+}
+
+func TestUnknownKeyError(t *testing.T) {
+	tests := []struct {
+		name   string
+		loader Loader
+		key    string
+	}{
+		{
+			name: "toml",
+			loader: &TOMLLoader{
+				Reader:              strings.NewReader("Name = \"koding\"\n[Postgres]\nFoo = 1\n"),
+				DisallowUnknownKeys: true,
+			},
+			key: "Postgres.Foo",
+		},
+		{
+			name: "json",
+			loader: &JSONLoader{
+				Reader:              strings.NewReader(`{"name": "koding", "postgres": {"foo": 1}}`),
+				DisallowUnknownKeys: true,
+			},
+			key: "postgres.foo",
+		},
+		{
+			name: "yaml",
+			loader: &YAMLLoader{
+				Reader:              strings.NewReader("name: koding\npostgres:\n  foo: 1\n"),
+				DisallowUnknownKeys: true,
+			},
+			key: "postgres.foo",
+		},
+		{
+			name: "hcl",
+			loader: &HCLLoader{
+				Reader:              strings.NewReader("name = \"koding\"\npostgres {\n  foo = 1\n}\n"),
+				DisallowUnknownKeys: true,
+			},
+			key: "postgres.foo",
+		},
+	}
+
+	for _, test := range tests {
+		err := test.loader.Load(&Server{})
+
+		var kerr *UnknownKeyError
+		if !errors.As(err, &kerr) {
+			t.Errorf("%s: error should be an *UnknownKeyError, got: %v", test.name, err)
+			continue
+		}
+
+		if kerr.Key != test.key {
+			t.Errorf("%s: Key is wrong: %s, want: %s", test.name, kerr.Key, test.key)
+		}
+	}
+
+	// keys are allowed by default
+	l := &JSONLoader{Reader: strings.NewReader(`{"foo": 1}`)}
+	if err := l.Load(&Server{}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLoaderError(t *testing.T) {
+	l := MultiLoader(&TagLoader{}, &JSONLoader{})
+
+	err := l.Load(&Server{})
+
+	var lerr *LoaderError
+	if !errors.As(err, &lerr) {
+		t.Fatalf("error should be a *LoaderError, got: %v", err)
+	}
+
+	if lerr.Loader != "JSONLoader" {
+		t.Errorf("Loader is wrong: %s, want: %s", lerr.Loader, "JSONLoader")
+	}
+
+	if !errors.Is(err, ErrSourceNotSet) {
+		t.Errorf("error should wrap ErrSourceNotSet, got: %v", err)
+	}
+
+	// nested MultiLoaders don't wrap the error again
+	err = MultiLoader(l).Load(&Server{})
+	if want := "multiconfig: JSONLoader: config path or reader is not set"; err.Error() != want {
+		t.Errorf("error is wrong: %s, want: %s", err, want)
+	}
+}
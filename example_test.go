@@ -98,6 +98,22 @@ func ExampleEnvironmentLoader() {
 	// Port--> 6060
 }
 
+func ExampleEnvironmentLoader_PrintEnvs() {
+	type ServerConfig struct {
+		Name     string
+		Password string `secret:"true"`
+	}
+
+	// List the "_FILE" variables of secrets, i.e: for Docker secrets
+	l := &EnvironmentLoader{FileIndirection: true}
+	l.PrintEnvs(&ServerConfig{})
+
+	// Output:
+	//    SERVERCONFIG_NAME
+	//    SERVERCONFIG_PASSWORD
+	//    SERVERCONFIG_PASSWORD_FILE
+}
+
 func ExampleTOMLLoader() {
 	// Our struct which is used for configuration
 	type ServerConfig struct {
@@ -178,3 +194,57 @@ func ExampleYAMLLoader() {
 	// Host--> koding
 	// Users--> [ankara istanbul]
 }
+
+func ExampleHCLLoader() {
+	// Our struct which is used for configuration
+	type ServerConfig struct {
+		Name     string
+		Port     int
+		Enabled  bool
+		Users    []string
+		Postgres Postgres
+	}
+
+	// Instantiate loader
+	l := &HCLLoader{Path: testHCL}
+
+	s := &ServerConfig{}
+	err := l.Load(s)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println("Host-->", s.Name)
+	fmt.Println("Users-->", s.Users)
+
+	// Output:
+	// Host--> koding
+	// Users--> [ankara istanbul]
+}
+
+func ExampleINILoader() {
+	// Our struct which is used for configuration
+	type ServerConfig struct {
+		Name     string
+		Port     int
+		Enabled  bool
+		Users    []string
+		Postgres Postgres
+	}
+
+	// Instantiate loader
+	l := &INILoader{Path: testINI}
+
+	s := &ServerConfig{}
+	err := l.Load(s)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println("Host-->", s.Name)
+	fmt.Println("Postgres Port-->", s.Postgres.Port)
+
+	// Output:
+	// Host--> koding
+	// Postgres Port--> 5432
+}
@@ -1,12 +1,16 @@
 package multiconfig
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"io/fs"
 	"io/ioutil"
-	"os"
-	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 	yaml "gopkg.in/yaml.v2"
@@ -18,13 +22,172 @@ var (
 
 	// ErrFileNotFound states that given file is not exists
 	ErrFileNotFound = errors.New("config file not found")
+
+	// ErrUnknownFormat states that the format of the config file can't be
+	// detected, or that the format set explicitly isn't registered
+	ErrUnknownFormat = errors.New("unknown config file format")
 )
 
+// FileLoader satisifies the loader interface. It loads the configuration from
+// the given file or Reader in any of the registered formats. The format is
+// chosen by the file's extension. If the extension is unknown, or if there is
+// no file name, the format is detected by the content: JSON, XML, TOML, HCL,
+// YAML and INI are detected in that order, followed by the formats added with
+// RegisterFormat.
+//
+// The file is read from FS if it's set, i.e: an embed.FS, otherwise from the
+// OS file system.
+type FileLoader struct {
+	Path   string
+	Reader io.Reader
+	FS     fs.FS
+
+	// Format overrides the format detection, i.e: "toml". Built-in formats
+	// are "json", "jsonc", "xml", "toml", "hcl", "yaml", "ini", "properties"
+	// and "dotenv", more can be added with RegisterFormat.
+	Format string
+
+	// Optional skips the file if it doesn't exist, instead of returning
+	// ErrFileNotFound.
+	Optional bool
+
+	// Includes enables the include directives of the file. The files listed
+	// in the top level "include" key are loaded before the file, which
+	// overrides them, with maps and slices merged as in MultiLoader:
+	//
+	//	include = ["common.toml", "db/*.toml"]
+	//
+	// In yaml files, a value can also be replaced with the content of a yaml
+	// or json file with the !include tag:
+	//
+	//	postgres: !include db/postgres.yaml
+	//
+	// Paths are relative to the including file and may be patterns, which
+	// are expanded in lexical order. Included files may include other files,
+	// a cycle returns an *IncludeError wrapping ErrIncludeCycle.
+	Includes bool
+
+	// Key decrypts the file, if it's encrypted, and its encrypted values,
+	// see EncryptionKey.
+	Key *EncryptionKey
+
+	// SOPS is the key material to decrypt the file if it's encrypted with
+	// SOPS. If nil, the keys are read from the SOPS environment, see
+	// SOPSKeys.
+	SOPS *SOPSKeys
+}
+
+// Load loads the source into the config defined by struct s.
+// Defaults to using the Reader if provided, otherwise tries to read from the
+// file. It returns a *ParseError wrapping ErrUnknownFormat if the format
+// can't be detected, or if Format isn't a registered format.
+func (f *FileLoader) Load(s interface{}) error {
+	var r io.Reader
+
+	// path is the path the file was found at, includes are relative to it
+	// and errors report it
+	path := f.Path
+
+	if f.Reader != nil {
+		r = f.Reader
+	} else if f.Path != "" {
+		file, found, err := fileSystem{f.FS}.open(f.Path)
+		if err == ErrFileNotFound && f.Optional {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r, path = file, found
+	} else {
+		return ErrSourceNotSet
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	if f.Key != nil {
+		if data, err = f.Key.decryptFile(data); err != nil {
+			return &ParseError{Path: path, Err: err}
+		}
+	}
+
+	format, err := f.format(path, data)
+	if err != nil {
+		return err
+	}
+
+	if format.Name == "yaml" || format.Name == "json" {
+		if data, err = f.SOPS.decryptSOPS(data, format.Name); err != nil {
+			return &ParseError{Path: path, Err: err}
+		}
+	}
+
+	if f.Includes {
+		err = loadIncludes(fileSystem{f.FS}, path, data, format, s, nil)
+	} else {
+		err = format.NewLoader(bytes.NewReader(data)).Load(s)
+	}
+
+	if err != nil {
+		var perr *ParseError
+		if errors.As(err, &perr) && perr.Path == "" {
+			perr.Path = path
+		}
+		return err
+	}
+
+	if f.Key != nil {
+		return f.Key.decryptValues(s)
+	}
+
+	return nil
+}
+
+// SkipMerge implements the MergeSkipper interface. It returns true if Includes
+// is set, as the included files are merged by the MultiLoader it executes.
+func (f *FileLoader) SkipMerge() bool {
+	return f.Includes
+}
+
+// format returns the format of the file at path with the given content.
+func (f *FileLoader) format(path string, data []byte) (Format, error) {
+	if f.Format != "" {
+		format, ok := LookupFormat(f.Format)
+		if !ok {
+			return format, &ParseError{Path: path, Err: fmt.Errorf("%w: %q", ErrUnknownFormat, f.Format)}
+		}
+		return format, nil
+	}
+
+	if format, ok := FormatForPath(path); ok {
+		return format, nil
+	}
+
+	if format, ok := formatByContent(data); ok {
+		return format, nil
+	}
+
+	return Format{}, &ParseError{Path: path, Err: ErrUnknownFormat}
+}
+
 // TOMLLoader satisifies the loader interface. It loads the configuration from
 // the given toml file or Reader.
 type TOMLLoader struct {
 	Path   string
 	Reader io.Reader
+	FS     fs.FS
+
+	// DisallowUnknownKeys causes Load to return an *UnknownKeyError if the
+	// source contains a key that doesn't match any field of the struct.
+	DisallowUnknownKeys bool
+
+	// Key decrypts the file, if it's encrypted, and its encrypted values,
+	// see EncryptionKey.
+	Key *EncryptionKey
 }
 
 // Load loads the source into the config defined by struct s
@@ -36,7 +199,7 @@ func (t *TOMLLoader) Load(s interface{}) error {
 	if t.Reader != nil {
 		r = t.Reader
 	} else if t.Path != "" {
-		file, err := getConfig(t.Path)
+		file, _, err := fileSystem{t.FS}.open(t.Path)
 		if err != nil {
 			return err
 		}
@@ -46,10 +209,30 @@ func (t *TOMLLoader) Load(s interface{}) error {
 		return ErrSourceNotSet
 	}
 
-	if _, err := toml.DecodeReader(r, s); err != nil {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
 		return err
 	}
 
+	if t.Key != nil {
+		if data, err = t.Key.decryptFile(data); err != nil {
+			return &ParseError{Path: t.Path, Err: err}
+		}
+	}
+
+	md, err := toml.Decode(string(data), s)
+	if err != nil {
+		return &ParseError{Path: t.Path, Err: err}
+	}
+
+	if keys := md.Undecoded(); t.DisallowUnknownKeys && len(keys) > 0 {
+		return &UnknownKeyError{Key: keys[0].String()}
+	}
+
+	if t.Key != nil {
+		return t.Key.decryptValues(s)
+	}
+
 	return nil
 }
 
@@ -58,6 +241,25 @@ func (t *TOMLLoader) Load(s interface{}) error {
 type JSONLoader struct {
 	Path   string
 	Reader io.Reader
+	FS     fs.FS
+
+	// Relaxed enables a relaxed syntax in the form of JSONC and JSON5. It
+	// allows // and /* */ comments, trailing commas, unquoted object keys and
+	// single quoted strings.
+	Relaxed bool
+
+	// DisallowUnknownKeys causes Load to return an *UnknownKeyError if the
+	// source contains a key that doesn't match any field of the struct.
+	DisallowUnknownKeys bool
+
+	// Key decrypts the file, if it's encrypted, and its encrypted values,
+	// see EncryptionKey.
+	Key *EncryptionKey
+
+	// SOPS is the key material to decrypt the file if it's encrypted with
+	// SOPS. If nil, the keys are read from the SOPS environment, see
+	// SOPSKeys.
+	SOPS *SOPSKeys
 }
 
 // Load loads the source into the config defined by struct s.
@@ -68,7 +270,7 @@ func (j *JSONLoader) Load(s interface{}) error {
 	if j.Reader != nil {
 		r = j.Reader
 	} else if j.Path != "" {
-		file, err := getConfig(j.Path)
+		file, _, err := fileSystem{j.FS}.open(j.Path)
 		if err != nil {
 			return err
 		}
@@ -78,7 +280,47 @@ func (j *JSONLoader) Load(s interface{}) error {
 		return ErrSourceNotSet
 	}
 
-	return json.NewDecoder(r).Decode(s)
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	if j.Key != nil {
+		if data, err = j.Key.decryptFile(data); err != nil {
+			return &ParseError{Path: j.Path, Err: err}
+		}
+	}
+
+	if j.Relaxed {
+		if data, err = standardizeJSON(data); err != nil {
+			return &ParseError{Path: j.Path, Err: err}
+		}
+	}
+
+	if data, err = j.SOPS.decryptSOPS(data, "json"); err != nil {
+		return &ParseError{Path: j.Path, Err: err}
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return &ParseError{Path: j.Path, Err: err}
+	}
+
+	if j.DisallowUnknownKeys {
+		var m map[string]interface{}
+		if err := json.Unmarshal(data, &m); err != nil {
+			return &ParseError{Path: j.Path, Err: err}
+		}
+
+		if err := checkUnknownKeys(m, s, "json", strings.EqualFold); err != nil {
+			return err
+		}
+	}
+
+	if j.Key != nil {
+		return j.Key.decryptValues(s)
+	}
+
+	return nil
 }
 
 // YAMLLoader satisifies the loader interface. It loads the configuration from
@@ -86,6 +328,20 @@ func (j *JSONLoader) Load(s interface{}) error {
 type YAMLLoader struct {
 	Path   string
 	Reader io.Reader
+	FS     fs.FS
+
+	// DisallowUnknownKeys causes Load to return an *UnknownKeyError if the
+	// source contains a key that doesn't match any field of the struct.
+	DisallowUnknownKeys bool
+
+	// Key decrypts the file, if it's encrypted, and its encrypted values,
+	// see EncryptionKey.
+	Key *EncryptionKey
+
+	// SOPS is the key material to decrypt the file if it's encrypted with
+	// SOPS. If nil, the keys are read from the SOPS environment, see
+	// SOPSKeys.
+	SOPS *SOPSKeys
 }
 
 // Load loads the source into the config defined by struct s.
@@ -97,7 +353,7 @@ func (y *YAMLLoader) Load(s interface{}) error {
 	if y.Reader != nil {
 		r = y.Reader
 	} else if y.Path != "" {
-		file, err := getConfig(y.Path)
+		file, _, err := fileSystem{y.FS}.open(y.Path)
 		if err != nil {
 			return err
 		}
@@ -112,28 +368,147 @@ func (y *YAMLLoader) Load(s interface{}) error {
 		return err
 	}
 
-	return yaml.Unmarshal(data, s)
+	if y.Key != nil {
+		if data, err = y.Key.decryptFile(data); err != nil {
+			return &ParseError{Path: y.Path, Err: err}
+		}
+	}
+
+	if data, err = y.SOPS.decryptSOPS(data, "yaml"); err != nil {
+		return &ParseError{Path: y.Path, Err: err}
+	}
+
+	if err := yaml.Unmarshal(data, s); err != nil {
+		return &ParseError{Path: y.Path, Err: err}
+	}
+
+	if y.DisallowUnknownKeys {
+		var m map[string]interface{}
+		if err := yaml.Unmarshal(data, &m); err != nil {
+			return &ParseError{Path: y.Path, Err: err}
+		}
+
+		// yaml matches the lowercased field name only
+		match := func(key, name string) bool { return key == strings.ToLower(name) }
+		if err := checkUnknownKeys(m, s, "yaml", match); err != nil {
+			return err
+		}
+	}
+
+	if y.Key != nil {
+		return y.Key.decryptValues(s)
+	}
+
+	return nil
 }
 
-func getConfig(path string) (*os.File, error) {
-	pwd, err := os.Getwd()
-	if err != nil {
-		return nil, err
+// checkUnknownKeys returns an *UnknownKeyError for the first key of the
+// decoded map m that doesn't match any field of the struct s. A key matches a
+// field if it's equal to the name in the field's tagName tag, or if match
+// reports true for the key and the field's name.
+func checkUnknownKeys(m map[string]interface{}, s interface{}, tagName string, match func(key, name string) bool) error {
+	return checkKeys("", m, reflect.TypeOf(s), tagName, match)
+}
+
+func checkKeys(prefix string, m map[string]interface{}, t reflect.Type, tagName string, match func(key, name string) bool) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
 	}
 
-	configPath := path
-	if !filepath.IsAbs(path) {
-		configPath = filepath.Join(pwd, path)
+	if t.Kind() != reflect.Struct {
+		return nil
 	}
 
-	// check if file with combined path is exists(relative path)
-	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
-		return os.Open(configPath)
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
 
-	f, err := os.Open(path)
-	if os.IsNotExist(err) {
-		return nil, ErrFileNotFound
+	for _, key := range keys {
+		field, ok := lookupField(t, key, tagName, match)
+		if !ok {
+			return &UnknownKeyError{Key: prefix + key}
+		}
+
+		var values []interface{}
+		switch v := m[key].(type) {
+		case []interface{}:
+			values = v
+		case []map[string]interface{}:
+			for _, m := range v {
+				values = append(values, m)
+			}
+		default:
+			values = []interface{}{v}
+		}
+
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr || ft.Kind() == reflect.Slice {
+			ft = ft.Elem()
+		}
+
+		for _, v := range values {
+			nested := toStringMap(v)
+			if nested == nil {
+				continue
+			}
+
+			if err := checkKeys(prefix+key+".", nested, ft, tagName, match); err != nil {
+				return err
+			}
+		}
 	}
-	return f, err
+
+	return nil
+}
+
+// lookupField returns the exported field of the struct type t matching the
+// given key. Fields of embedded structs are looked up too.
+func lookupField(t reflect.Type, key, tagName string, match func(key, name string) bool) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get(tagName), ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		if name == key || (name == "" && match(key, field.Name)) {
+			return field, true
+		}
+
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if field.Anonymous && ft.Kind() == reflect.Struct {
+			if f, ok := lookupField(ft, key, tagName, match); ok {
+				return f, true
+			}
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// toStringMap converts the nested maps returned by the decoders to
+// map[string]interface{}. It returns nil if v is not a map.
+func toStringMap(v interface{}) map[string]interface{} {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m
+	case map[interface{}]interface{}:
+		sm := make(map[string]interface{}, len(m))
+		for key, val := range m {
+			sm[fmt.Sprint(key)] = val
+		}
+		return sm
+	}
+
+	return nil
 }
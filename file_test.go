@@ -1,7 +1,11 @@
 package multiconfig
 
 import (
+	"errors"
+	"io/ioutil"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -85,7 +89,403 @@ func TestJSON_Reader(t *testing.T) {
 	testStruct(t, s, getDefaultServer())
 }
 
+func TestJSON5(t *testing.T) {
+	m := NewWithPath(testJSON5)
+
+	s := &Server{}
+	if err := m.Load(s); err != nil {
+		t.Error(err)
+	}
+
+	testStruct(t, s, getDefaultServer())
+}
+
+func TestStandardizeJSON(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{src: `{"a": 1}`, want: `{"a": 1}`},
+		{src: `{a: 1, $b_2: 'x'}`, want: `{"a": 1, "$b_2": "x"}`},
+		{src: `{"a": [1, 2,], }`, want: `{"a": [1, 2 ]  }`},
+		{src: `{"a": 1, // comment` + "\n" + `}`, want: `{"a": 1   ` + "\n" + `}`},
+		{src: `{"a": /* b */ 1e10, "b": true}`, want: `{"a":   1e10, "b": true}`},
+		{src: `{'a': 'it\'s "quoted" // not a comment'}`, want: `{"a": "it's \"quoted\" // not a comment"}`},
+	}
+
+	for _, test := range tests {
+		got, err := standardizeJSON([]byte(test.src))
+		if err != nil {
+			t.Errorf("%s: %s", test.src, err)
+			continue
+		}
+
+		if string(got) != test.want {
+			t.Errorf("%s: got %s, want: %s", test.src, got, test.want)
+		}
+	}
+
+	for _, src := range []string{`{"a": "b}`, `{"a": 1 /* }`} {
+		l := &JSONLoader{Reader: strings.NewReader(src), Relaxed: true}
+
+		var perr *ParseError
+		if err := l.Load(&Server{}); !errors.As(err, &perr) {
+			t.Errorf("%s: error should be a *ParseError, got: %v", src, err)
+		}
+	}
+}
+
+func TestHCL(t *testing.T) {
+	m := NewWithPath(testHCL)
+
+	s := &Server{}
+	if err := m.Load(s); err != nil {
+		t.Error(err)
+	}
+
+	testStruct(t, s, getDefaultServer())
+}
+
+func TestHCL_Reader(t *testing.T) {
+	f, err := os.Open(testHCL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	l := MultiLoader(&TagLoader{}, &HCLLoader{Reader: f})
+	s := &Server{}
+	if err := l.Load(s); err != nil {
+		t.Error(err)
+	}
+
+	testStruct(t, s, getDefaultServer())
+}
+
+func TestHCL_Blocks(t *testing.T) {
+	type Listener struct {
+		Address string
+		Port    int
+	}
+
+	s := &struct {
+		Postgres  Postgres
+		Listeners []Listener `hcl:"listener"`
+	}{}
+
+	l := &HCLLoader{Reader: strings.NewReader(`
+postgres {
+  port = 5432
+}
+
+listener {
+  address = "127.0.0.1"
+  port    = 80
+}
+
+listener {
+  address = "10.0.0.1"
+  port    = 443
+}
+`)}
+
+	if err := l.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Postgres.Port != 5432 {
+		t.Errorf("Postgres Port value is wrong: %d, want: %d", s.Postgres.Port, 5432)
+	}
+
+	want := []Listener{{"127.0.0.1", 80}, {"10.0.0.1", 443}}
+	if len(s.Listeners) != len(want) {
+		t.Fatalf("Listeners value is wrong: %+v, want: %+v", s.Listeners, want)
+	}
+
+	for i, listener := range want {
+		if s.Listeners[i] != listener {
+			t.Errorf("Listener is wrong for index: %d, listener: %+v, want: %+v", i, s.Listeners[i], listener)
+		}
+	}
+}
+
 // func TestJSON2(t *testing.T) {
 // 	ExampleEnvironmentLoader()
 // 	ExampleTOMLLoader()
 // }
+
+func TestINI(t *testing.T) {
+	m := NewWithPath(testINI)
+
+	s := &Server{}
+	if err := m.Load(s); err != nil {
+		t.Error(err)
+	}
+
+	testStruct(t, s, getDefaultServer())
+}
+
+func TestINI_Reader(t *testing.T) {
+	f, err := os.Open(testINI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	l := MultiLoader(&TagLoader{}, &INILoader{Reader: f})
+	s := &Server{}
+	if err := l.Load(s); err != nil {
+		t.Error(err)
+	}
+
+	testStruct(t, s, getDefaultServer())
+}
+
+func TestINI_InlineComments(t *testing.T) {
+	src := `name = "koding ; not a comment" # comment
+[postgres]
+enabled = 'true'	; comment
+port = 5432 ; default port
+hosts = 192.168.2.1,192.168.2.2 # hosts
+dbname = config#db;name
+`
+
+	s := &Server{}
+	if err := (&INILoader{Reader: strings.NewReader(src)}).Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "koding ; not a comment"; s.Name != want {
+		t.Errorf("Name value is wrong: %s, want: %s", s.Name, want)
+	}
+
+	if !s.Postgres.Enabled {
+		t.Errorf("Postgres.Enabled value is wrong: %t, want: %t", s.Postgres.Enabled, true)
+	}
+
+	if s.Postgres.Port != 5432 {
+		t.Errorf("Postgres.Port value is wrong: %d, want: %d", s.Postgres.Port, 5432)
+	}
+
+	if want := []string{"192.168.2.1", "192.168.2.2"}; !reflect.DeepEqual(s.Postgres.Hosts, want) {
+		t.Errorf("Postgres.Hosts value is wrong: %v, want: %v", s.Postgres.Hosts, want)
+	}
+
+	if s.Postgres.DBName != "config#db;name" {
+		t.Errorf("Postgres.DBName value is wrong: %s, want: %s", s.Postgres.DBName, "config#db;name")
+	}
+}
+
+func TestINI_Errors(t *testing.T) {
+	tests := []struct {
+		src  string
+		line int
+	}{
+		{src: "name = koding\n[postgres\n", line: 2},
+		{src: "name = koding\n\nport\n", line: 3},
+		{src: "[postgres]\n# comment\nport = foo\n", line: 3},
+	}
+
+	for _, test := range tests {
+		l := &INILoader{Reader: strings.NewReader(test.src)}
+
+		err := l.Load(&Server{})
+
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%q: error should be a *ParseError, got: %v", test.src, err)
+			continue
+		}
+
+		if perr.Line != test.line {
+			t.Errorf("%q: Line is wrong: %d, want: %d", test.src, perr.Line, test.line)
+		}
+	}
+
+	l := &INILoader{
+		Reader:              strings.NewReader("[postgres]\nport = 5432\nfoo = bar\n"),
+		DisallowUnknownKeys: true,
+	}
+
+	var kerr *UnknownKeyError
+	if err := l.Load(&Server{}); !errors.As(err, &kerr) || kerr.Key != "postgres.foo" {
+		t.Errorf("error should be an *UnknownKeyError for postgres.foo, got: %v", err)
+	}
+}
+
+func TestXML(t *testing.T) {
+	m := NewWithPath(testXML)
+
+	s := &Server{}
+	if err := m.Load(s); err != nil {
+		t.Error(err)
+	}
+
+	testStruct(t, s, getDefaultServer())
+}
+
+func TestXML_Reader(t *testing.T) {
+	f, err := os.Open(testXML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	l := MultiLoader(&TagLoader{}, &XMLLoader{Reader: f})
+	s := &Server{}
+	if err := l.Load(s); err != nil {
+		t.Error(err)
+	}
+
+	testStruct(t, s, getDefaultServer())
+}
+
+func TestXML_Tags(t *testing.T) {
+	type Listener struct {
+		Address string `xml:"addr,attr"`
+		Port    int    `xml:",chardata"`
+	}
+
+	s := &struct {
+		Name      string     `xml:"name,attr"`
+		Listeners []Listener `xml:"listener"`
+		Backends  []Listener
+		Private   *URL
+		Ignored   string `xml:"-"`
+	}{}
+
+	l := &XMLLoader{Reader: strings.NewReader(`
+<config name="koding">
+  <listener addr="127.0.0.1">80</listener>
+  <listener addr="10.0.0.1">443</listener>
+  <backends>
+    <backend addr="10.0.0.2">8080</backend>
+  </backends>
+  <private>http://127.0.0.1/kite</private>
+  <ignored>value</ignored>
+</config>
+`)}
+
+	if err := l.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Name != "koding" {
+		t.Errorf("Name value is wrong: %s, want: %s", s.Name, "koding")
+	}
+
+	want := []Listener{{"127.0.0.1", 80}, {"10.0.0.1", 443}}
+	if len(s.Listeners) != len(want) {
+		t.Fatalf("Listeners value is wrong: %+v, want: %+v", s.Listeners, want)
+	}
+
+	for i, listener := range want {
+		if s.Listeners[i] != listener {
+			t.Errorf("Listener is wrong for index: %d, listener: %+v, want: %+v", i, s.Listeners[i], listener)
+		}
+	}
+
+	if len(s.Backends) != 1 || s.Backends[0] != (Listener{"10.0.0.2", 8080}) {
+		t.Errorf("Backends value is wrong: %+v", s.Backends)
+	}
+
+	if s.Private == nil || s.Private.String() != "http://127.0.0.1/kite" {
+		t.Errorf("Private value is wrong: %v", s.Private)
+	}
+
+	if s.Ignored != "" {
+		t.Errorf("Ignored value should not be set: %s", s.Ignored)
+	}
+}
+
+func TestXML_Errors(t *testing.T) {
+	l := &XMLLoader{Reader: strings.NewReader("<server>\n<port>6060</port>\n</srv>")}
+
+	var perr *ParseError
+	if err := l.Load(&Server{}); !errors.As(err, &perr) || perr.Line != 3 {
+		t.Errorf("error should be a *ParseError at line 3, got: %v", err)
+	}
+
+	l = &XMLLoader{
+		Reader:              strings.NewReader(`<server><postgres port="5432" foo="bar"/></server>`),
+		DisallowUnknownKeys: true,
+	}
+
+	var kerr *UnknownKeyError
+	if err := l.Load(&Server{}); !errors.As(err, &kerr) || kerr.Key != "postgres.foo" {
+		t.Errorf("error should be an *UnknownKeyError for postgres.foo, got: %v", err)
+	}
+}
+
+func TestFileLoader(t *testing.T) {
+	for _, path := range []string{testTOML, testJSON, testJSON5, testYAML, testHCL, testINI, testXML, testProperties} {
+		l := MultiLoader(&TagLoader{}, &FileLoader{Path: path})
+
+		s := &Server{}
+		if err := l.Load(s); err != nil {
+			t.Errorf("%s: %s", path, err)
+			continue
+		}
+
+		testStruct(t, s, getDefaultServer())
+	}
+}
+
+func TestFileLoader_Sniff(t *testing.T) {
+	for _, path := range []string{testTOML, testJSON, testJSON5, testYAML, testHCL, testINI, testXML} {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the extension of app.conf is unknown, the content is sniffed
+		l := MultiLoader(&TagLoader{}, &FileLoader{Path: "app.conf", Reader: strings.NewReader(string(data))})
+
+		s := &Server{}
+		if err := l.Load(s); err != nil {
+			t.Errorf("%s: %s", path, err)
+			continue
+		}
+
+		testStruct(t, s, getDefaultServer())
+	}
+}
+
+func TestFileLoader_Format(t *testing.T) {
+	l := &FileLoader{
+		Reader: strings.NewReader("name = koding\n"),
+		Format: "properties",
+	}
+
+	s := &Server{}
+	if err := l.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Name != "koding" {
+		t.Errorf("Name value is wrong: %s, want: %s", s.Name, "koding")
+	}
+
+	l = &FileLoader{Reader: strings.NewReader(""), Format: "foo"}
+	if err := l.Load(s); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("error should be ErrUnknownFormat, got: %v", err)
+	}
+}
+
+func TestFileLoader_UnknownFormat(t *testing.T) {
+	l := &FileLoader{Path: "app.conf", Reader: strings.NewReader("this is not a config file")}
+
+	err := l.Load(&Server{})
+	if !errors.Is(err, ErrUnknownFormat) {
+		t.Fatalf("error should be ErrUnknownFormat, got: %v", err)
+	}
+
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Path != "app.conf" {
+		t.Errorf("error should be a *ParseError for app.conf, got: %v", err)
+	}
+
+	// NewWithPath doesn't skip files of unknown format
+	if err := NewWithPath("testdata/demo.go").Load(&Server{}); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("error should be ErrUnknownFormat, got: %v", err)
+	}
+}
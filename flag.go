@@ -52,6 +52,23 @@ type FlagLoader struct {
 	// that will used in passed into the flag for Usage.
 	FlagUsageFunc func(name string) string
 
+	// ConfigFlag is the name of the flag that gives the path of the config
+	// file, read by ConfigPathLoader. The flag is only defined so it's
+	// accepted and shown in the usage, its value is ignored.
+	ConfigFlag string
+
+	// ConfigEnv is just a placeholder to print the environment variable that
+	// gives the path of the config file in the usage, see ConfigPathLoader.
+	ConfigEnv string
+
+	// ProfileFlag is the name of the flag that selects the profile, read by
+	// ProfileLoader. Like ConfigFlag, its value is ignored.
+	ProfileFlag string
+
+	// ProfileEnv is just a placeholder to print the environment variable that
+	// selects the profile in the usage, see ProfileLoader.
+	ProfileEnv string
+
 	// only exists for testing.  This is the raw flagset that is to parse
 	flagSet *flag.FlagSet
 }
@@ -65,7 +82,23 @@ func (f *FlagLoader) Load(s interface{}) error {
 	f.flagSet = flagSet
 
 	for _, field := range strct.Fields() {
-		f.processField(field.Name(), field)
+		f.processField(field.Name(), field.Name(), field)
+	}
+
+	if f.ConfigFlag != "" {
+		usage := "Path of the config file."
+		if f.ConfigEnv != "" {
+			usage = fmt.Sprintf("Path of the config file, or $%s.", f.ConfigEnv)
+		}
+		flagSet.String(f.ConfigFlag, "", usage)
+	}
+
+	if f.ProfileFlag != "" {
+		usage := "Profile of the config file."
+		if f.ProfileEnv != "" {
+			usage = fmt.Sprintf("Profile of the config file, or $%s.", f.ProfileEnv)
+		}
+		flagSet.String(f.ProfileFlag, "", usage)
 	}
 
 	flagSet.Usage = func() {
@@ -77,6 +110,11 @@ func (f *FlagLoader) Load(s interface{}) error {
 			CamelCase: f.CamelCase,
 		}
 		e.PrintEnvs(s)
+		for _, env := range []string{f.ConfigEnv, f.ProfileEnv} {
+			if env != "" {
+				fmt.Println("  ", env)
+			}
+		}
 		fmt.Println("")
 	}
 
@@ -105,8 +143,8 @@ func filterArgs(args []string) []string {
 
 // processField generates a flag based on the given field and fieldName. If a
 // nested struct is detected, a flag for each field of that nested struct is
-// generated too.
-func (f *FlagLoader) processField(fieldName string, field *structs.Field) error {
+// generated too. path is the path of the field.
+func (f *FlagLoader) processField(fieldName, path string, field *structs.Field) error {
 	if f.CamelCase {
 		fieldName = strings.Join(camelcase.Split(fieldName), "-")
 		fieldName = strings.Replace(fieldName, "---", "-", -1)
@@ -131,7 +169,7 @@ func (f *FlagLoader) processField(fieldName string, field *structs.Field) error
 				flagName = ff.Name()
 			}
 
-			if err := f.processField(flagName, ff); err != nil {
+			if err := f.processField(flagName, fieldPath(path, ff.Name()), ff); err != nil {
 				return err
 			}
 		}
@@ -143,7 +181,7 @@ func (f *FlagLoader) processField(fieldName string, field *structs.Field) error
 
 		// we only can get the value from expored fields, unexported fields panics
 		if field.IsExported() {
-			f.flagSet.Var(newFieldValue(field), flagName(fieldName), f.flagUsage(fieldName, field))
+			f.flagSet.Var(newFieldValue(field, path), flagName(fieldName), f.flagUsage(fieldName, field))
 		}
 	}
 
@@ -166,16 +204,18 @@ func (f *FlagLoader) flagUsage(fieldName string, field *structs.Field) string {
 // fieldValue satisfies the flag.Value and flag.Getter interfaces
 type fieldValue struct {
 	field *structs.Field
+	path  string
 }
 
-func newFieldValue(f *structs.Field) *fieldValue {
+func newFieldValue(f *structs.Field, path string) *fieldValue {
 	return &fieldValue{
 		field: f,
+		path:  path,
 	}
 }
 
 func (f *fieldValue) Set(val string) error {
-	return fieldSet(f.field, val)
+	return fieldSet(f.field, f.path, val)
 }
 
 func (f *fieldValue) String() string {
@@ -0,0 +1,215 @@
+package multiconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/hashicorp/hcl"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Format describes a configuration file format. Formats are registered with
+// RegisterFormat and used by FileLoader and NewWithPath.
+type Format struct {
+	// Name of the format, i.e: "toml". It's used to choose the format with
+	// FileLoader.Format.
+	Name string
+
+	// Extensions of the files in this format, including the leading dot, i.e:
+	// ".toml".
+	Extensions []string
+
+	// NewLoader returns a loader that loads the content of a file in this
+	// format from r.
+	NewLoader func(r io.Reader) Loader
+
+	// Sniff reports whether the content of a file is in this format. It's
+	// optional, a format without Sniff is only chosen by its name or
+	// extensions.
+	Sniff func(data []byte) bool
+}
+
+var (
+	formatsMu sync.RWMutex
+
+	// formats are sniffed in this order, so ambiguous formats come last
+	formats = []Format{
+		{
+			Name:       "json",
+			Extensions: []string{".json"},
+			NewLoader:  func(r io.Reader) Loader { return &JSONLoader{Reader: r} },
+			Sniff:      sniffJSON,
+		},
+		{
+			Name:       "jsonc",
+			Extensions: []string{".jsonc", ".json5"},
+			NewLoader:  func(r io.Reader) Loader { return &JSONLoader{Reader: r, Relaxed: true} },
+			Sniff:      sniffJSONC,
+		},
+		{
+			Name:       "xml",
+			Extensions: []string{".xml"},
+			NewLoader:  func(r io.Reader) Loader { return &XMLLoader{Reader: r} },
+			Sniff:      sniffXML,
+		},
+		{
+			Name:       "toml",
+			Extensions: []string{".toml"},
+			NewLoader:  func(r io.Reader) Loader { return &TOMLLoader{Reader: r} },
+			Sniff:      sniffTOML,
+		},
+		{
+			Name:       "hcl",
+			Extensions: []string{".hcl"},
+			NewLoader:  func(r io.Reader) Loader { return &HCLLoader{Reader: r} },
+			Sniff:      sniffHCL,
+		},
+		{
+			Name:       "yaml",
+			Extensions: []string{".yaml", ".yml"},
+			NewLoader:  func(r io.Reader) Loader { return &YAMLLoader{Reader: r} },
+			Sniff:      sniffYAML,
+		},
+		{
+			Name:       "ini",
+			Extensions: []string{".ini"},
+			NewLoader:  func(r io.Reader) Loader { return &INILoader{Reader: r} },
+			Sniff:      sniffINI,
+		},
+		{
+			Name:       "properties",
+			Extensions: []string{".properties"},
+			NewLoader:  func(r io.Reader) Loader { return &PropertiesLoader{Reader: r} },
+		},
+		{
+			Name:       "dotenv",
+			Extensions: []string{".env"},
+			NewLoader:  func(r io.Reader) Loader { return &DotEnvLoader{Reader: r} },
+		},
+	}
+)
+
+// RegisterFormat registers the given file format. A format with the same name
+// is replaced. The extensions of the format take precedence over the ones of
+// the formats registered before, and its content is sniffed after theirs. It
+// panics if the format has no name or no NewLoader function.
+func RegisterFormat(format Format) {
+	if format.Name == "" || format.NewLoader == nil {
+		panic("multiconfig: format must have a name and a NewLoader function")
+	}
+
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	for i, f := range formats {
+		if strings.EqualFold(f.Name, format.Name) {
+			formats = append(formats[:i:i], formats[i+1:]...)
+			break
+		}
+	}
+
+	formats = append(formats, format)
+}
+
+// Formats returns the registered file formats in the order they're sniffed.
+func Formats() []Format {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	return append([]Format(nil), formats...)
+}
+
+// LookupFormat returns the registered format with the given name. Names are
+// case-insensitive.
+func LookupFormat(name string) (Format, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	for _, format := range formats {
+		if strings.EqualFold(format.Name, name) {
+			return format, true
+		}
+	}
+
+	return Format{}, false
+}
+
+// FormatForPath returns the registered format for the extension of the given
+// path. Extensions are case-insensitive.
+func FormatForPath(path string) (Format, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return Format{}, false
+	}
+
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	for i := len(formats) - 1; i >= 0; i-- {
+		for _, e := range formats[i].Extensions {
+			if strings.ToLower(e) == ext {
+				return formats[i], true
+			}
+		}
+	}
+
+	return Format{}, false
+}
+
+// formatByContent returns the first format that the given content is
+// detected to be in.
+func formatByContent(data []byte) (Format, bool) {
+	for _, format := range Formats() {
+		if format.Sniff != nil && format.Sniff(data) {
+			return format, true
+		}
+	}
+
+	return Format{}, false
+}
+
+func sniffJSON(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && (data[0] == '{' || data[0] == '[') && json.Valid(data)
+}
+
+func sniffJSONC(data []byte) bool {
+	data, err := standardizeJSON(data)
+	return err == nil && sniffJSON(data)
+}
+
+func sniffXML(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '<' {
+		return false
+	}
+
+	_, err := parseXML(bytes.NewReader(data))
+	return err == nil
+}
+
+func sniffTOML(data []byte) bool {
+	var m map[string]interface{}
+	_, err := toml.Decode(string(data), &m)
+	return err == nil
+}
+
+func sniffHCL(data []byte) bool {
+	var m map[string]interface{}
+	return hcl.Unmarshal(data, &m) == nil
+}
+
+func sniffYAML(data []byte) bool {
+	var m map[string]interface{}
+	return yaml.Unmarshal(data, &m) == nil && len(m) > 0
+}
+
+func sniffINI(data []byte) bool {
+	values, err := parseINI("", bytes.NewReader(data))
+	return err == nil && len(values) > 0
+}
@@ -0,0 +1,93 @@
+package multiconfig
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// upperLoader is a custom format for testing, it sets the Name field from the
+// first line of the content, which must be upper case.
+type upperLoader struct {
+	r io.Reader
+}
+
+func (u *upperLoader) Load(s interface{}) error {
+	scanner := bufio.NewScanner(u.r)
+	scanner.Scan()
+	s.(*Server).Name = strings.ToLower(scanner.Text())
+	return scanner.Err()
+}
+
+func registerUpperFormat(t *testing.T) {
+	saved := Formats()
+	t.Cleanup(func() { formats = saved })
+
+	RegisterFormat(Format{
+		Name:       "upper",
+		Extensions: []string{".upper", ".toml"},
+		NewLoader:  func(r io.Reader) Loader { return &upperLoader{r: r} },
+		Sniff: func(data []byte) bool {
+			return len(data) > 0 && bytes.Equal(data, bytes.ToUpper(data))
+		},
+	})
+}
+
+func TestRegisterFormat(t *testing.T) {
+	registerUpperFormat(t)
+
+	if _, ok := LookupFormat("UPPER"); !ok {
+		t.Error("format upper should be registered")
+	}
+
+	if f, ok := FormatForPath("testdata/config.toml"); !ok || f.Name != "upper" {
+		t.Errorf("registered extension should take precedence, got: %s", f.Name)
+	}
+
+	if f, ok := FormatForPath("config.YML"); !ok || f.Name != "yaml" {
+		t.Errorf("format of config.YML is wrong: %s, want: %s", f.Name, "yaml")
+	}
+
+	if _, ok := FormatForPath("config"); ok {
+		t.Error("config should not have a format")
+	}
+
+	s := &Server{}
+	if err := (&FileLoader{Path: testTOML}).Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(s.Name, "name ") {
+		t.Errorf("Name value is wrong: %s", s.Name)
+	}
+
+	// sniffed after the built-in formats
+	l := &FileLoader{Reader: strings.NewReader("KODING!")}
+	if err := l.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Name != "koding!" {
+		t.Errorf("Name value is wrong: %s, want: %s", s.Name, "koding!")
+	}
+}
+
+func TestRegisterFormatReplace(t *testing.T) {
+	registerUpperFormat(t)
+
+	n := len(Formats())
+	RegisterFormat(Format{
+		Name:      "upper",
+		NewLoader: func(r io.Reader) Loader { return &upperLoader{r: r} },
+	})
+
+	if len(Formats()) != n {
+		t.Errorf("format should be replaced, got %d formats, want: %d", len(Formats()), n)
+	}
+
+	if f, _ := FormatForPath("testdata/config.toml"); f.Name != "toml" {
+		t.Errorf("format of config.toml is wrong: %s, want: %s", f.Name, "toml")
+	}
+}
@@ -0,0 +1,157 @@
+package multiconfig
+
+import (
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// fileSystem reads the config files from fsys, or from the OS file system if
+// fsys is nil. Paths in fsys are slash-separated, as required by fs.FS.
+type fileSystem struct {
+	fsys fs.FS
+}
+
+// open opens the config file with the given path and returns it with the
+// path it was found at. Relative paths are searched in the search paths, see
+// FindConfig.
+func (f fileSystem) open(name string) (fs.File, string, error) {
+	configPath, err := f.find(name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var file fs.File
+	if f.fsys == nil {
+		file, err = os.Open(configPath)
+	} else {
+		file, err = f.fsys.Open(configPath)
+	}
+
+	return file, configPath, err
+}
+
+// find returns the path of the config file with the given name in the first
+// search path that contains it. In fsys, absolute search paths are skipped.
+func (f fileSystem) find(name string) (string, error) {
+	if f.isAbs(name) {
+		if _, err := f.stat(name); os.IsNotExist(err) {
+			return "", ErrFileNotFound
+		}
+		return name, nil
+	}
+
+	for _, dir := range SearchPaths() {
+		if f.fsys != nil && (filepath.IsAbs(dir) || path.IsAbs(dir)) {
+			continue
+		}
+
+		p := f.join(dir, name)
+		if _, err := f.stat(p); !os.IsNotExist(err) {
+			return p, nil
+		}
+	}
+
+	return "", ErrFileNotFound
+}
+
+// readFile reads the file with the given path, without searching it. It
+// returns ErrFileNotFound if the file doesn't exist.
+func (f fileSystem) readFile(name string) ([]byte, error) {
+	var data []byte
+	var err error
+
+	if f.fsys == nil {
+		data, err = ioutil.ReadFile(name)
+	} else {
+		data, err = fs.ReadFile(f.fsys, f.clean(name))
+	}
+
+	if os.IsNotExist(err) {
+		return nil, ErrFileNotFound
+	}
+
+	return data, err
+}
+
+// readDir returns the entries of the given directory sorted by name.
+func (f fileSystem) readDir(name string) ([]fs.DirEntry, error) {
+	if f.fsys == nil {
+		return os.ReadDir(name)
+	}
+
+	return fs.ReadDir(f.fsys, f.clean(name))
+}
+
+// stat returns the info of the given file, following symlinks.
+func (f fileSystem) stat(name string) (fs.FileInfo, error) {
+	if f.fsys == nil {
+		return os.Stat(name)
+	}
+
+	return fs.Stat(f.fsys, f.clean(name))
+}
+
+// glob returns the paths of the files matching the pattern in lexical order.
+func (f fileSystem) glob(pattern string) ([]string, error) {
+	if f.fsys == nil {
+		return filepath.Glob(pattern)
+	}
+
+	return fs.Glob(f.fsys, f.clean(pattern))
+}
+
+func (f fileSystem) join(elem ...string) string {
+	if f.fsys == nil {
+		return filepath.Join(elem...)
+	}
+
+	return path.Join(elem...)
+}
+
+func (f fileSystem) dir(name string) string {
+	if f.fsys == nil {
+		return filepath.Dir(name)
+	}
+
+	return path.Dir(name)
+}
+
+func (f fileSystem) isAbs(name string) bool {
+	if f.fsys == nil {
+		return filepath.IsAbs(name)
+	}
+
+	// fs.FS paths are always relative to its root
+	return false
+}
+
+// clean returns the name as a valid fs.FS path, i.e: "./a/b" as "a/b".
+func (f fileSystem) clean(name string) string {
+	return path.Clean(name)
+}
+
+// sameFile reports whether the given paths refer to the same file.
+func (f fileSystem) sameFile(a, b string) bool {
+	if f.fsys != nil {
+		return path.Clean(a) == path.Clean(b)
+	}
+
+	if filepath.Clean(a) == filepath.Clean(b) {
+		return true
+	}
+
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+
+	bi, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+
+	return os.SameFile(ai, bi)
+}
@@ -0,0 +1,101 @@
+package multiconfig
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+var testFS = fstest.MapFS{
+	"config.toml": {Data: []byte(`
+include = ["conf.d/*"]
+
+name = "koding"
+users = ["ankara"]
+`)},
+	"conf.d/10-port.json": {Data: []byte(`{"port": 7070}`)},
+	"conf.d/20-users":     {Data: []byte("users:\n  - istanbul\n")},
+	"config.env":          {Data: []byte("FSCONFIG_NAME=dotenv\n")},
+	"secrets/..data/port": {Data: []byte("8080\n")},
+	"secrets/port":        {Data: []byte("1111\n")},
+}
+
+type FSConfig struct {
+	Name  string
+	Port  int
+	Users []string `merge:"append"`
+}
+
+func TestFileLoaderFS(t *testing.T) {
+	s := &FSConfig{}
+	if err := (&FileLoader{Path: "config.toml", FS: testFS, Includes: true}).Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Name != "koding" {
+		t.Errorf("Name value is wrong: %s, want: %s", s.Name, "koding")
+	}
+
+	if s.Port != 7070 {
+		t.Errorf("Port value is wrong: %d, want: %d", s.Port, 7070)
+	}
+
+	if want := []string{"istanbul", "ankara"}; !reflect.DeepEqual(s.Users, want) {
+		t.Errorf("Users value is wrong: %v, want: %v", s.Users, want)
+	}
+
+	err := (&FileLoader{Path: "missing.toml", FS: testFS}).Load(s)
+	if err != ErrFileNotFound {
+		t.Errorf("error should be ErrFileNotFound, got: %v", err)
+	}
+}
+
+func TestDirLoaderFS(t *testing.T) {
+	s := &FSConfig{}
+	if err := (&DirLoader{Path: "conf.d", FS: testFS, Include: []string{"*"}}).Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Port != 7070 {
+		t.Errorf("Port value is wrong: %d, want: %d", s.Port, 7070)
+	}
+
+	if want := []string{"istanbul"}; !reflect.DeepEqual(s.Users, want) {
+		t.Errorf("Users value is wrong: %v, want: %v", s.Users, want)
+	}
+}
+
+func TestDotEnvLoaderFS(t *testing.T) {
+	s := &FSConfig{}
+	if err := (&DotEnvLoader{Path: "config.env", FS: testFS}).Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Name != "dotenv" {
+		t.Errorf("Name value is wrong: %s, want: %s", s.Name, "dotenv")
+	}
+}
+
+func TestMountedFilesLoaderFS(t *testing.T) {
+	s := &FSConfig{}
+	if err := (&MountedFilesLoader{Path: "secrets", FS: testFS}).Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Port != 8080 {
+		t.Errorf("Port value is wrong: %d, want: %d", s.Port, 8080)
+	}
+}
+
+func TestSearchPathsFS(t *testing.T) {
+	setSearchPaths(t, "/etc/fsconfig", "conf.d")
+
+	s := &FSConfig{}
+	if err := (&JSONLoader{Path: "10-port.json", FS: testFS}).Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Port != 7070 {
+		t.Errorf("Port value is wrong: %d, want: %d", s.Port, 7070)
+	}
+}
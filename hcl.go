@@ -0,0 +1,146 @@
+package multiconfig
+
+import (
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+)
+
+// HCLLoader satisifies the loader interface. It loads the configuration from
+// the given hcl file or Reader. Blocks are mapped to nested structs and
+// repeated blocks to slices of structs:
+//
+//	postgres {
+//	  port = 5432
+//	}
+//
+//	listener {
+//	  address = "127.0.0.1"
+//	}
+//
+//	listener {
+//	  address = "10.0.0.1"
+//	}
+type HCLLoader struct {
+	Path   string
+	Reader io.Reader
+	FS     fs.FS
+
+	// DisallowUnknownKeys causes Load to return an *UnknownKeyError if the
+	// source contains a key that doesn't match any field of the struct.
+	DisallowUnknownKeys bool
+}
+
+// Load loads the source into the config defined by struct s.
+// Defaults to using the Reader if provided, otherwise tries to read from the
+// file
+func (h *HCLLoader) Load(s interface{}) error {
+	var r io.Reader
+
+	if h.Reader != nil {
+		r = h.Reader
+	} else if h.Path != "" {
+		file, _, err := fileSystem{h.FS}.open(h.Path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	} else {
+		return ErrSourceNotSet
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	file, err := hcl.ParseBytes(data)
+	if err != nil {
+		return &ParseError{Path: h.Path, Err: err}
+	}
+
+	if list, ok := file.Node.(*ast.ObjectList); ok {
+		groupBlocks(list, reflect.TypeOf(s))
+	}
+
+	if err := hcl.DecodeObject(s, file); err != nil {
+		return &ParseError{Path: h.Path, Err: err}
+	}
+
+	if h.DisallowUnknownKeys {
+		var m map[string]interface{}
+		if err := hcl.Unmarshal(data, &m); err != nil {
+			return &ParseError{Path: h.Path, Err: err}
+		}
+
+		return checkUnknownKeys(m, s, "hcl", strings.EqualFold)
+	}
+
+	return nil
+}
+
+// groupBlocks rewrites repeated blocks that are decoded into a slice of
+// structs as a single list of objects. The hcl decoder otherwise creates a
+// slice element for each key of the blocks.
+func groupBlocks(list *ast.ObjectList, t reflect.Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	items := make([]*ast.ObjectItem, 0, len(list.Items))
+	grouped := make(map[string]*ast.ListType)
+
+	for _, item := range list.Items {
+		obj, ok := item.Val.(*ast.ObjectType)
+		if !ok || len(item.Keys) == 0 {
+			items = append(items, item)
+			continue
+		}
+
+		key, _ := item.Keys[0].Token.Value().(string)
+		field, ok := lookupField(t, key, "hcl", strings.EqualFold)
+		if !ok {
+			items = append(items, item)
+			continue
+		}
+
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if ft.Kind() != reflect.Slice || len(item.Keys) != 1 {
+			groupBlocks(obj.List, ft)
+			items = append(items, item)
+			continue
+		}
+
+		groupBlocks(obj.List, ft.Elem())
+
+		key = strings.ToLower(key)
+		if l, ok := grouped[key]; ok {
+			l.Add(obj)
+			continue
+		}
+
+		l := &ast.ListType{List: []ast.Node{obj}}
+		grouped[key] = l
+		items = append(items, &ast.ObjectItem{
+			Keys:   item.Keys,
+			Assign: item.Keys[0].Pos(),
+			Val:    l,
+		})
+	}
+
+	list.Items = items
+}
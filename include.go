@@ -0,0 +1,240 @@
+package multiconfig
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// includeDirective is decoded from a config file to find the files it
+// includes.
+type includeDirective struct {
+	Include []string
+}
+
+// yamlInclude matches the !include tags of yaml values, i.e:
+//
+//	postgres: !include postgres.yaml
+//	hosts:
+//	  - !include host.yaml
+var yamlInclude = regexp.MustCompile(`(?m)((?:^|:|-)[ \t]+|^)!include[ \t]+("[^"\n]*"|'[^'\n]*'|[^ \t\n#]+)`)
+
+// includeMarker prefixes the paths of the !include tags replaced by
+// markIncludes.
+const includeMarker = "\x00include:"
+
+// loadIncludes loads the file with the given path, content and format into
+// s, after the files it includes. chain are the paths of the including files.
+func loadIncludes(fsys fileSystem, path string, data []byte, format Format, s interface{}, chain []string) error {
+	if path != "" {
+		chain = append(chain[:len(chain):len(chain)], path)
+	}
+
+	var d includeDirective
+	if err := format.NewLoader(bytes.NewReader(data)).Load(&d); err != nil {
+		return err
+	}
+
+	paths, err := resolveIncludes(fsys, fsys.dir(path), d.Include)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range paths {
+		if err := includeFile(fsys, p, s, chain); err != nil {
+			return err
+		}
+	}
+
+	if format.Name == "yaml" {
+		if data, err = expandYAMLIncludes(fsys, path, data, chain); err != nil {
+			return err
+		}
+	}
+
+	prev := snapshot(s)
+
+	if err := format.NewLoader(bytes.NewReader(data)).Load(s); err != nil {
+		var perr *ParseError
+		if errors.As(err, &perr) && perr.Path == "" {
+			perr.Path = path
+		}
+		return err
+	}
+
+	return mergeLoaded(prev, s)
+}
+
+// includeFile loads the included file with the given path into s. Errors are
+// wrapped in an *IncludeError with the chain of includes.
+func includeFile(fsys fileSystem, path string, s interface{}, chain []string) error {
+	includeErr := func(err error) error {
+		var ierr *IncludeError
+		if errors.As(err, &ierr) {
+			return err
+		}
+
+		return &IncludeError{Chain: append(chain[:len(chain):len(chain)], path), Err: err}
+	}
+
+	data, err := readInclude(fsys, path, chain)
+	if err != nil {
+		return includeErr(err)
+	}
+
+	format, err := (&FileLoader{FS: fsys.fsys}).format(path, data)
+	if err != nil {
+		return includeErr(err)
+	}
+
+	if err := loadIncludes(fsys, path, data, format, s, chain); err != nil {
+		return includeErr(err)
+	}
+
+	return nil
+}
+
+// readInclude returns the content of the included file with the given path.
+// It returns ErrIncludeCycle if the file is in the chain of includes.
+func readInclude(fsys fileSystem, path string, chain []string) ([]byte, error) {
+	for _, p := range chain {
+		if fsys.sameFile(p, path) {
+			return nil, ErrIncludeCycle
+		}
+	}
+
+	return fsys.readFile(path)
+}
+
+// resolveIncludes returns the paths of the included files. Relative paths are
+// resolved relative to dir, patterns are expanded to the matching files in
+// lexical order.
+func resolveIncludes(fsys fileSystem, dir string, includes []string) ([]string, error) {
+	var paths []string
+
+	for _, include := range includes {
+		if !fsys.isAbs(include) {
+			include = fsys.join(dir, include)
+		}
+
+		if !strings.ContainsAny(include, "*?[") {
+			paths = append(paths, include)
+			continue
+		}
+
+		matches, err := fsys.glob(include)
+		if err != nil {
+			return nil, err
+		}
+
+		paths = append(paths, matches...)
+	}
+
+	return paths, nil
+}
+
+// expandYAMLIncludes replaces the values tagged with !include in the yaml
+// content of the file with the given path by the content of the included yaml
+// or json files.
+func expandYAMLIncludes(fsys fileSystem, path string, data []byte, chain []string) ([]byte, error) {
+	if !yamlInclude.Match(data) {
+		return data, nil
+	}
+
+	var v interface{}
+	if err := yaml.Unmarshal(markIncludes(data), &v); err != nil {
+		return nil, &ParseError{Path: path, Err: err}
+	}
+
+	v, err := replaceIncludes(fsys, fsys.dir(path), v, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(v)
+}
+
+// markIncludes replaces the !include tags with strings of their paths
+// prefixed with includeMarker, as yaml.v2 doesn't expose the tags of values.
+func markIncludes(data []byte) []byte {
+	return yamlInclude.ReplaceAllFunc(data, func(m []byte) []byte {
+		sub := yamlInclude.FindSubmatch(m)
+
+		include := string(sub[2])
+		if unquoted, err := strconv.Unquote(include); err == nil {
+			include = unquoted
+		} else if strings.HasPrefix(include, "'") {
+			include = strings.Trim(include, "'")
+		}
+
+		return append(sub[1], strconv.Quote(includeMarker+include)...)
+	})
+}
+
+// replaceIncludes replaces the marked includes of the decoded yaml value v
+// with the decoded content of the included files.
+func replaceIncludes(fsys fileSystem, dir string, v interface{}, chain []string) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		if !strings.HasPrefix(v, includeMarker) {
+			return v, nil
+		}
+
+		path := strings.TrimPrefix(v, includeMarker)
+		if !fsys.isAbs(path) {
+			path = fsys.join(dir, path)
+		}
+
+		included, err := decodeYAMLInclude(fsys, path, chain)
+		if err != nil {
+			var ierr *IncludeError
+			if errors.As(err, &ierr) {
+				return nil, err
+			}
+
+			return nil, &IncludeError{Chain: append(chain[:len(chain):len(chain)], path), Err: err}
+		}
+
+		return included, nil
+	case map[interface{}]interface{}:
+		for key, val := range v {
+			val, err := replaceIncludes(fsys, dir, val, chain)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = val
+		}
+	case []interface{}:
+		for i, val := range v {
+			val, err := replaceIncludes(fsys, dir, val, chain)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = val
+		}
+	}
+
+	return v, nil
+}
+
+// decodeYAMLInclude decodes the yaml or json file with the given path,
+// included with an !include tag.
+func decodeYAMLInclude(fsys fileSystem, path string, chain []string) (interface{}, error) {
+	data, err := readInclude(fsys, path, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	chain = append(chain[:len(chain):len(chain)], path)
+
+	var v interface{}
+	if err := yaml.Unmarshal(markIncludes(data), &v); err != nil {
+		return nil, &ParseError{Path: path, Err: err}
+	}
+
+	return replaceIncludes(fsys, fsys.dir(path), v, chain)
+}
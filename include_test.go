@@ -0,0 +1,121 @@
+package multiconfig
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type IncludeConfig struct {
+	Name     string
+	Port     int
+	Users    []string `merge:"append"`
+	Postgres Postgres
+}
+
+func TestFileLoaderIncludes(t *testing.T) {
+	s := &IncludeConfig{}
+	f := &FileLoader{Path: filepath.Join(testInclude, "main.toml"), Includes: true}
+	if err := f.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Name != "koding" {
+		t.Errorf("Name value is wrong: %s, want: %s", s.Name, "koding")
+	}
+
+	if s.Port != 6060 {
+		t.Errorf("Port value is wrong: %d, want: %d", s.Port, 6060)
+	}
+
+	if want := []string{"ankara", "istanbul"}; !reflect.DeepEqual(s.Users, want) {
+		t.Errorf("Users value is wrong: %v, want: %v", s.Users, want)
+	}
+
+	if s.Postgres.DBName != "configdb" || s.Postgres.Port != 5432 || !s.Postgres.Enabled {
+		t.Errorf("Postgres value is wrong: %+v", s.Postgres)
+	}
+}
+
+func TestFileLoaderIncludesSearchPaths(t *testing.T) {
+	setSearchPaths(t, "testdata/missing", testInclude)
+
+	s := &IncludeConfig{}
+	if err := (&FileLoader{Path: "main.toml", Includes: true}).Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Port != 6060 {
+		t.Errorf("Port value is wrong: %d, want: %d", s.Port, 6060)
+	}
+
+	if s.Postgres.DBName != "configdb" {
+		t.Errorf("Postgres value is wrong: %+v", s.Postgres)
+	}
+}
+
+func TestFileLoaderIncludesDisabled(t *testing.T) {
+	s := &IncludeConfig{}
+	if err := (&FileLoader{Path: filepath.Join(testInclude, "main.toml")}).Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Port != 0 {
+		t.Errorf("Port value is wrong: %d, want: %d", s.Port, 0)
+	}
+}
+
+func TestFileLoaderYAMLInclude(t *testing.T) {
+	s := &IncludeConfig{}
+	f := &FileLoader{Path: filepath.Join(testInclude, "main.yaml"), Includes: true}
+	if err := f.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Name != "koding" {
+		t.Errorf("Name value is wrong: %s, want: %s", s.Name, "koding")
+	}
+
+	if s.Postgres.DBName != "configdb" || s.Postgres.Port != 5432 || !s.Postgres.Enabled {
+		t.Errorf("Postgres value is wrong: %+v", s.Postgres)
+	}
+}
+
+func TestFileLoaderIncludeCycle(t *testing.T) {
+	f := &FileLoader{Path: filepath.Join(testInclude, "cycle-a.toml"), Includes: true}
+
+	err := f.Load(&IncludeConfig{})
+	if !errors.Is(err, ErrIncludeCycle) {
+		t.Fatalf("error should be ErrIncludeCycle, got: %v", err)
+	}
+
+	var ierr *IncludeError
+	if !errors.As(err, &ierr) {
+		t.Fatalf("error should be an *IncludeError, got: %v", err)
+	}
+
+	want := []string{
+		filepath.Join(testInclude, "cycle-a.toml"),
+		filepath.Join(testInclude, "cycle-b.toml"),
+		filepath.Join(testInclude, "cycle-a.toml"),
+	}
+
+	if !reflect.DeepEqual(ierr.Chain, want) {
+		t.Errorf("Chain is wrong: %v, want: %v", ierr.Chain, want)
+	}
+}
+
+func TestFileLoaderIncludeNotFound(t *testing.T) {
+	f := &FileLoader{Path: filepath.Join(testInclude, "missing.yaml"), Includes: true}
+
+	err := f.Load(&IncludeConfig{})
+	if !errors.Is(err, ErrFileNotFound) {
+		t.Fatalf("error should be ErrFileNotFound, got: %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "nothere.toml") {
+		t.Errorf("error should name the missing file, got: %v", err)
+	}
+}
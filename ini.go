@@ -0,0 +1,224 @@
+package multiconfig
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/fatih/structs"
+)
+
+// INILoader satisifies the loader interface. It loads the configuration from
+// the given ini file or Reader. Keys before the first section are mapped to
+// the fields of the struct, keys of a section are mapped to the fields of the
+// nested struct with the same name. Sections of deeper nested structs are
+// separated by dots:
+//
+//	name = koding
+//
+//	[postgres]
+//	port = 5432
+//	hosts = 192.168.2.1,192.168.2.2
+//
+// Lines starting with ; or # are comments, and so is a ; or # preceded by
+// whitespace after an unquoted value, i.e: port = 5432 ; default port.
+// Section and key names are case-insensitive. The name can be changed with
+// the "ini" tag. Values are converted in the same way as environment
+// variables and flags.
+type INILoader struct {
+	Path   string
+	Reader io.Reader
+	FS     fs.FS
+
+	// DisallowUnknownKeys causes Load to return an *UnknownKeyError if the
+	// source contains a key that doesn't match any field of the struct.
+	DisallowUnknownKeys bool
+}
+
+// keyValue is a single key/value pair of a file, i.e: an ini file.
+type keyValue struct {
+	key   string // as written in the file, prefixed with the section
+	value string
+	line  int
+	used  bool
+}
+
+// checkUnusedKeys returns an *UnknownKeyError for the first value that
+// wasn't used to set a field.
+func checkUnusedKeys(values map[string]*keyValue) error {
+	unused := make([]*keyValue, 0)
+	for _, v := range values {
+		if !v.used {
+			unused = append(unused, v)
+		}
+	}
+
+	if len(unused) == 0 {
+		return nil
+	}
+
+	sort.Slice(unused, func(a, b int) bool { return unused[a].line < unused[b].line })
+	return &UnknownKeyError{Key: unused[0].key}
+}
+
+// Load loads the source into the config defined by struct s.
+// Defaults to using the Reader if provided, otherwise tries to read from the
+// file
+func (i *INILoader) Load(s interface{}) error {
+	var r io.Reader
+
+	if i.Reader != nil {
+		r = i.Reader
+	} else if i.Path != "" {
+		file, _, err := fileSystem{i.FS}.open(i.Path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	} else {
+		return ErrSourceNotSet
+	}
+
+	values, err := parseINI(i.Path, r)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range structs.Fields(s) {
+		if err := i.processField("", "", field, values); err != nil {
+			return err
+		}
+	}
+
+	if i.DisallowUnknownKeys {
+		return checkUnusedKeys(values)
+	}
+
+	return nil
+}
+
+// processField looks up the value of the field in the given section and sets
+// it. The fields of nested structs are looked up in their own section. path
+// is the path of the struct of the field.
+func (i *INILoader) processField(section, path string, field *structs.Field, values map[string]*keyValue) error {
+	if !field.IsExported() {
+		return nil
+	}
+
+	name := strings.Split(field.Tag("ini"), ",")[0]
+	if name == "-" {
+		return nil
+	}
+
+	if name == "" {
+		name = field.Name()
+	}
+
+	path = fieldPath(path, field.Name())
+
+	key := strings.ToLower(name)
+	if section != "" {
+		key = section + "." + key
+	}
+
+	switch field.Kind() {
+	case reflect.Struct:
+		for _, f := range field.Fields() {
+			if err := i.processField(key, path, f, values); err != nil {
+				return err
+			}
+		}
+	default:
+		v, ok := values[key]
+		if !ok {
+			return nil
+		}
+		v.used = true
+
+		if err := fieldSet(field, path, v.value); err != nil {
+			return &ParseError{Path: i.Path, Line: v.line, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// parseINI parses the ini content of r, path is only used for errors. The
+// returned values are keyed by the lowercased section and key names,
+// separated by a dot.
+func parseINI(path string, r io.Reader) (map[string]*keyValue, error) {
+	values := make(map[string]*keyValue)
+	section, sectionKey := "", ""
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case text == "" || text[0] == ';' || text[0] == '#':
+			continue
+		case text[0] == '[':
+			if !strings.HasSuffix(text, "]") {
+				return nil, &ParseError{Path: path, Line: line, Err: errors.New("section is not closed")}
+			}
+
+			section = strings.TrimSpace(text[1 : len(text)-1])
+			sectionKey = strings.ToLower(section)
+			continue
+		}
+
+		i := strings.IndexAny(text, "=:")
+		if i < 0 {
+			return nil, &ParseError{Path: path, Line: line, Err: errors.New("expected key = value")}
+		}
+
+		key := strings.TrimSpace(text[:i])
+		if key == "" {
+			return nil, &ParseError{Path: path, Line: line, Err: errors.New("key is empty")}
+		}
+
+		v := &keyValue{key: key, value: iniValue(text[i+1:]), line: line}
+		if section != "" {
+			v.key = section + "." + key
+			key = sectionKey + "." + strings.ToLower(key)
+		}
+
+		values[strings.ToLower(key)] = v
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// iniValue returns the value of a key without its quotes and its inline
+// comment. A ; or # preceded by whitespace starts a comment, unless it's
+// quoted.
+func iniValue(raw string) string {
+	value := strings.TrimSpace(raw)
+
+	if value != "" && (value[0] == '"' || value[0] == '\'') {
+		if i := strings.IndexByte(value[1:], value[0]); i >= 0 {
+			// only a comment may follow the closing quote
+			if rest := strings.TrimSpace(value[i+2:]); rest == "" || rest[0] == ';' || rest[0] == '#' {
+				return value[1 : i+1]
+			}
+		}
+		return value
+	}
+
+	for _, sep := range []string{" ;", "\t;", " #", "\t#"} {
+		if i := strings.Index(value, sep); i >= 0 {
+			value = value[:i]
+		}
+	}
+
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,258 @@
+package multiconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// InterpolationLoader satisifies the loader interface. It expands the
+// environment variables referenced in the string values of the config
+// struct, as loaded by the previous loaders, i.e: the default values of
+// TagLoader and the values of the file loaders:
+//
+//	data_dir = "${HOME}/.myapp"
+//	dsn = "postgres://${DB_HOST:-localhost}:5432"
+//	token = "${TOKEN:?token is required}"
+//
+// ${VAR:-default} expands to the default if VAR is unset or empty, and
+// ${VAR:?message} returns a *FieldError with the message instead. $$ is
+// expanded to a literal $. Only string fields, including the strings of
+// slices and maps, are expanded, as the values of the other fields are
+// converted by the previous loaders: `default:"${PORT}"` or port = "${PORT}"
+// for an int field return an error. Set TagLoader.ExpandEnv to expand the
+// default values of fields of any type. It's placed in MultiLoader after the
+// loaders whose values should be expanded:
+//
+//	MultiLoader(&TagLoader{}, &TOMLLoader{Path: path}, &InterpolationLoader{}, &EnvironmentLoader{})
+type InterpolationLoader struct {
+	// KeepReferences leaves the references to the fields of the struct, i.e:
+	// ${DataDir} or ${Postgres.Port}, escaped or not, to a ReferenceLoader
+	// placed after the other loaders.
+	KeepReferences bool
+}
+
+// Load expands the variables in the config defined by struct s.
+func (i *InterpolationLoader) Load(s interface{}) error {
+	root := reflect.ValueOf(s).Elem()
+
+	var isField func(path string) bool
+	if i.KeepReferences {
+		isField = func(path string) bool {
+			_, ok := lookupPath(root, path)
+			return ok
+		}
+	}
+
+	return interpolate(root, "", func(v string) (string, error) {
+		return expandEnv(v, os.LookupEnv, isField)
+	})
+}
+
+// SkipMerge implements the MergeSkipper interface. It returns true, as it
+// transforms the values loaded by the previous loaders.
+func (i *InterpolationLoader) SkipMerge() bool {
+	return true
+}
+
+// interpolate replaces the strings of v with the result of expand. path is
+// the name of the field, the fields of nested structs are separated by dots.
+func interpolate(v reflect.Value, path string, expand func(string) (string, error)) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if sf.PkgPath != "" {
+				continue
+			}
+
+			name := sf.Name
+			if path != "" {
+				name = path + "." + sf.Name
+			}
+
+			if err := interpolate(v.Field(i), name, expand); err != nil {
+				return err
+			}
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return interpolate(v.Elem(), path, expand)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := interpolate(v.Index(i), path, expand); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			val := reflect.New(v.Type().Elem()).Elem()
+			val.Set(v.MapIndex(key))
+
+			if err := interpolate(val, path, expand); err != nil {
+				return err
+			}
+
+			v.SetMapIndex(key, val)
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+
+		val := reflect.New(v.Elem().Type()).Elem()
+		val.Set(v.Elem())
+
+		if err := interpolate(val, path, expand); err != nil {
+			return err
+		}
+
+		v.Set(val)
+	case reflect.String:
+		s, err := expand(v.String())
+		if err != nil {
+			return &FieldError{Field: path, Value: v.String(), Err: err}
+		}
+
+		v.SetString(s)
+	}
+
+	return nil
+}
+
+// expandEnv expands the ${VAR}, ${VAR:-default} and ${VAR:?message}
+// references of s with the given lookup function, and $$ to $. References
+// for which isField reports true are left as is, escaped or not. isField may
+// be nil.
+func expandEnv(s string, lookup func(name string) (string, bool), isField func(path string) bool) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		switch s[i+1] {
+		case '$':
+			if end := fieldRef(s, i+1, isField); end > 0 {
+				b.WriteString(s[i : end+1])
+				i = end
+				continue
+			}
+
+			b.WriteByte('$')
+			i++
+		case '{':
+			end := closingBrace(s, i+2)
+			if end < 0 {
+				b.WriteString(s[i:])
+				return b.String(), nil
+			}
+
+			if fieldRef(s, i, isField) > 0 {
+				b.WriteString(s[i : end+1])
+				i = end
+				continue
+			}
+
+			expanded, ok, err := expandRef(s[i+2:end], lookup, isField)
+			if err != nil {
+				return "", err
+			}
+
+			if ok {
+				b.WriteString(expanded)
+			} else {
+				b.WriteString(s[i : end+1])
+			}
+			i = end
+		default:
+			b.WriteByte('$')
+		}
+	}
+
+	return b.String(), nil
+}
+
+// expandRef expands the reference expr, the content of ${...}. It reports
+// false if expr doesn't reference a variable.
+func expandRef(expr string, lookup func(name string) (string, bool), isField func(path string) bool) (string, bool, error) {
+	n := 0
+	for n < len(expr) && isVarChar(rune(expr[n])) {
+		n++
+	}
+
+	name, rest := expr[:n], expr[n:]
+	if name == "" {
+		return "", false, nil
+	}
+
+	value, ok := lookup(name)
+
+	switch {
+	case rest == "":
+		return value, true, nil
+	case strings.HasPrefix(rest, ":-"):
+		if ok && value != "" {
+			return value, true, nil
+		}
+
+		value, err := expandEnv(rest[2:], lookup, isField)
+		return value, true, err
+	case strings.HasPrefix(rest, ":?"):
+		if ok && value != "" {
+			return value, true, nil
+		}
+
+		msg := rest[2:]
+		if msg == "" {
+			msg = fmt.Sprintf("%s is not set", name)
+		}
+		return "", true, errors.New(msg)
+	}
+
+	return "", false, nil
+}
+
+// fieldRef returns the index of the brace closing the reference to a field
+// that starts with the $ at i, or -1 if there is no such reference.
+func fieldRef(s string, i int, isField func(path string) bool) int {
+	if isField == nil || !strings.HasPrefix(s[i:], "${") {
+		return -1
+	}
+
+	end := strings.IndexByte(s[i:], '}')
+	if end < 0 || !isField(s[i+2:i+end]) {
+		return -1
+	}
+
+	return i + end
+}
+
+// closingBrace returns the index of the brace closing the reference that
+// starts at i, or -1 if it's not closed.
+func closingBrace(s string, i int) int {
+	depth := 1
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,157 @@
+package multiconfig
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	vars := map[string]string{
+		"HOME":  "/home/gopher",
+		"EMPTY": "",
+		"HOST":  "db",
+	}
+
+	lookup := func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain", "plain"},
+		{"${HOME}/.myapp", "/home/gopher/.myapp"},
+		{"${MISSING}", ""},
+		{"${MISSING:-localhost}:5432", "localhost:5432"},
+		{"${EMPTY:-default}", "default"},
+		{"${HOST:-localhost}", "db"},
+		{"${MISSING:-${HOST}}", "db"},
+		{"$${HOME}", "${HOME}"},
+		{"price: 5$", "price: 5$"},
+		{"$HOME", "$HOME"},
+		{"${Postgres.Port}", "${Postgres.Port}"},
+		{"${HOME", "${HOME"},
+	}
+
+	for _, test := range tests {
+		got, err := expandEnv(test.in, lookup, nil)
+		if err != nil {
+			t.Errorf("%q: %s", test.in, err)
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("%q: got: %q, want: %q", test.in, got, test.want)
+		}
+	}
+
+	if _, err := expandEnv("${EMPTY:?token is required}", lookup, nil); err == nil || err.Error() != "token is required" {
+		t.Errorf("error is wrong: %v", err)
+	}
+
+	if _, err := expandEnv("${MISSING:?}", lookup, nil); err == nil || err.Error() != "MISSING is not set" {
+		t.Errorf("error is wrong: %v", err)
+	}
+}
+
+type InterpolationConfig struct {
+	DataDir  string `default:"${INTERPOLATION_HOME}/.myapp"`
+	DSN      string
+	Hosts    []string
+	Labels   map[string]string
+	Postgres struct {
+		DBName string
+	}
+}
+
+func TestInterpolationLoader(t *testing.T) {
+	t.Setenv("INTERPOLATION_HOME", "/home/gopher")
+	t.Setenv("INTERPOLATION_HOST", "db")
+
+	json := `{
+	"dsn": "postgres://${INTERPOLATION_HOST:-localhost}:5432",
+	"hosts": ["${INTERPOLATION_HOST}", "$$literal"],
+	"labels": {"home": "${INTERPOLATION_HOME}"},
+	"postgres": {"dbname": "${INTERPOLATION_DB:-configdb}"}
+}`
+
+	m := MultiLoader(
+		&TagLoader{},
+		&JSONLoader{Reader: strings.NewReader(json)},
+		&InterpolationLoader{},
+	)
+
+	s := &InterpolationConfig{}
+	if err := m.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	want := &InterpolationConfig{
+		DataDir: "/home/gopher/.myapp",
+		DSN:     "postgres://db:5432",
+		Hosts:   []string{"db", "$literal"},
+		Labels:  map[string]string{"home": "/home/gopher"},
+	}
+	want.Postgres.DBName = "configdb"
+
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("config is wrong: %+v, want: %+v", s, want)
+	}
+}
+
+func TestInterpolationLoaderRequired(t *testing.T) {
+	s := &InterpolationConfig{}
+	s.Postgres.DBName = "${INTERPOLATION_MISSING:?database name is required}"
+
+	var ferr *FieldError
+	if err := (&InterpolationLoader{}).Load(s); !errors.As(err, &ferr) {
+		t.Fatalf("error should be a *FieldError, got: %v", err)
+	}
+
+	if ferr.Field != "Postgres.DBName" {
+		t.Errorf("Field is wrong: %s, want: %s", ferr.Field, "Postgres.DBName")
+	}
+
+	if ferr.Err.Error() != "database name is required" {
+		t.Errorf("Err is wrong: %s", ferr.Err)
+	}
+}
+
+func TestTagLoaderExpandEnv(t *testing.T) {
+	type Config struct {
+		Port    int    `default:"${INTERPOLATION_PORT:-8080}"`
+		Workers int    `default:"${INTERPOLATION_WORKERS:-4}"`
+		DataDir string `default:"${INTERPOLATION_HOME}/.myapp"`
+		LogDir  string `default:"${DataDir}/logs"`
+	}
+
+	t.Setenv("INTERPOLATION_PORT", "9090")
+	t.Setenv("INTERPOLATION_HOME", "/home/gopher")
+
+	s := &Config{}
+	if err := (&TagLoader{ExpandEnv: true}).Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Config{Port: 9090, Workers: 4, DataDir: "/home/gopher/.myapp", LogDir: "${DataDir}/logs"}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("config is wrong: %+v, want: %+v", s, want)
+	}
+
+	if err := (&TagLoader{}).Load(&Config{}); err == nil {
+		t.Error("error should be returned for an int field without ExpandEnv")
+	}
+
+	required := &struct {
+		Port int `default:"${INTERPOLATION_MISSING:?port is required}"`
+	}{}
+
+	var ferr *FieldError
+	if err := (&TagLoader{ExpandEnv: true}).Load(required); !errors.As(err, &ferr) || ferr.Field != "Port" {
+		t.Errorf("error should be a *FieldError for Port, got: %v", err)
+	}
+}
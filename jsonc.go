@@ -0,0 +1,149 @@
+package multiconfig
+
+import (
+	"bytes"
+	"errors"
+)
+
+// standardizeJSON converts relaxed JSON to standard JSON. It removes // and
+// /* */ comments and trailing commas, quotes unquoted object keys and
+// converts single quoted strings to double quoted ones. Comments are replaced
+// with whitespace, so line numbers don't change.
+func standardizeJSON(data []byte) ([]byte, error) {
+	var b bytes.Buffer
+	b.Grow(len(data))
+
+	for i := 0; i < len(data); {
+		c := data[i]
+
+		switch {
+		case c == '"' || c == '\'':
+			n, err := writeJSONString(&b, data[i:])
+			if err != nil {
+				return nil, err
+			}
+			i += n
+		case c == '/' && i+1 < len(data) && (data[i+1] == '/' || data[i+1] == '*'):
+			n, err := skipJSONComment(&b, data[i:])
+			if err != nil {
+				return nil, err
+			}
+			i += n
+		case c == ',':
+			// drop trailing commas
+			if j := skipJSONSpace(data, i+1); j < len(data) && (data[j] == '}' || data[j] == ']') {
+				b.WriteByte(' ')
+			} else {
+				b.WriteByte(c)
+			}
+			i++
+		case isJSONIdentStart(c):
+			j := i + 1
+			for j < len(data) && (isJSONIdentStart(data[j]) || data[j] >= '0' && data[j] <= '9') {
+				j++
+			}
+
+			// an identifier followed by a colon is an unquoted key
+			if k := skipJSONSpace(data, j); k < len(data) && data[k] == ':' {
+				b.WriteByte('"')
+				b.Write(data[i:j])
+				b.WriteByte('"')
+			} else {
+				b.Write(data[i:j])
+			}
+			i = j
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+// writeJSONString writes the string at the start of data as a double quoted
+// string and returns the number of bytes consumed.
+func writeJSONString(b *bytes.Buffer, data []byte) (int, error) {
+	quote := data[0]
+	b.WriteByte('"')
+
+	for i := 1; i < len(data); i++ {
+		c := data[i]
+
+		switch {
+		case c == quote:
+			b.WriteByte('"')
+			return i + 1, nil
+		case c == '\\' && i+1 < len(data):
+			i++
+			if data[i] == '\'' {
+				b.WriteByte('\'')
+			} else {
+				b.WriteByte('\\')
+				b.WriteByte(data[i])
+			}
+		case c == '"':
+			// only possible in single quoted strings
+			b.WriteString(`\"`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return 0, errors.New("unterminated string")
+}
+
+// skipJSONComment replaces the comment at the start of data with whitespace
+// and returns the number of bytes consumed.
+func skipJSONComment(b *bytes.Buffer, data []byte) (int, error) {
+	if data[1] == '/' {
+		end := bytes.IndexByte(data, '\n')
+		if end < 0 {
+			end = len(data)
+		}
+
+		b.WriteByte(' ')
+		return end, nil
+	}
+
+	end := bytes.Index(data[2:], []byte("*/"))
+	if end < 0 {
+		return 0, errors.New("unterminated comment")
+	}
+	end += 4
+
+	b.WriteByte(' ')
+	b.Write(bytes.Repeat([]byte("\n"), bytes.Count(data[:end], []byte("\n"))))
+	return end, nil
+}
+
+// skipJSONSpace returns the index of the first byte at or after i that is
+// neither whitespace nor part of a comment.
+func skipJSONSpace(data []byte, i int) int {
+	for i < len(data) {
+		switch c := data[i]; {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			end := bytes.IndexByte(data[i:], '\n')
+			if end < 0 {
+				return len(data)
+			}
+			i += end
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return len(data)
+			}
+			i += end + 4
+		default:
+			return i
+		}
+	}
+
+	return i
+}
+
+func isJSONIdentStart(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
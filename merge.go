@@ -0,0 +1,321 @@
+package multiconfig
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Merge strategies for slices, set with the "merge" tag of a field:
+//
+//	// Users loaded by each loader are appended to the previous ones.
+//	Users []string `merge:"append"`
+//
+// Maps are merged by key unless the "replace" strategy is set.
+const (
+	// MergeReplace replaces the previous value. It's the default for slices.
+	MergeReplace = "replace"
+
+	// MergeAppend appends the loaded items to the previous ones.
+	MergeAppend = "append"
+
+	// MergeUnique appends the loaded items to the previous ones, skipping the
+	// items that already exist.
+	MergeUnique = "unique"
+)
+
+// mergeTagName is the tag to set the merge strategy of a field.
+const mergeTagName = "merge"
+
+// loadSnapshot is the state of a struct before a loader runs.
+type loadSnapshot struct {
+	// prev is a deep copy of the struct
+	prev reflect.Value
+
+	// orig is a copy of the struct sharing its maps, to tell the maps
+	// changed in place from the maps replaced by the loader
+	orig reflect.Value
+}
+
+// snapshot returns the state of the struct pointed by s, to merge the values
+// set by a loader with the previous ones. The maps tagged with
+// merge:"replace" are set to nil, as the decoders fill the existing maps in
+// place, and restored by mergeLoaded if the loader doesn't set them. It
+// returns nil if s is not a pointer to a struct.
+func snapshot(s interface{}) *loadSnapshot {
+	v := reflect.ValueOf(s)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+
+	snap := &loadSnapshot{prev: deepCopy(v.Elem()), orig: copyStructs(v.Elem())}
+	clearReplaced(v.Elem())
+
+	return snap
+}
+
+// clearReplaced sets the maps of struct v, and of its nested structs, tagged
+// with merge:"replace" to nil.
+func clearReplaced(v reflect.Value) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		switch f := v.Field(i); f.Kind() {
+		case reflect.Struct:
+			clearReplaced(f)
+		case reflect.Ptr:
+			if !f.IsNil() && f.Elem().Kind() == reflect.Struct {
+				clearReplaced(f.Elem())
+			}
+		case reflect.Map:
+			if sf.Tag.Get(mergeTagName) == MergeReplace {
+				f.Set(reflect.Zero(f.Type()))
+			}
+		}
+	}
+}
+
+// mergeLoaded merges the maps and slices of the struct pointed by s, as set by
+// a loader, with their previous values in snap, which is returned by
+// snapshot.
+func mergeLoaded(snap *loadSnapshot, s interface{}) error {
+	if snap == nil {
+		return nil
+	}
+
+	return mergeStruct(snap.prev, snap.orig, reflect.ValueOf(s).Elem())
+}
+
+func mergeStruct(prev, orig, cur reflect.Value) error {
+	t := cur.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		strategy := sf.Tag.Get(mergeTagName)
+		switch strategy {
+		case "", MergeReplace, MergeAppend, MergeUnique:
+		default:
+			return &FieldError{
+				Field: sf.Name,
+				Err:   fmt.Errorf("unknown merge strategy %q", strategy),
+			}
+		}
+
+		p, o, c := prev.Field(i), orig.Field(i), cur.Field(i)
+
+		switch sf.Type.Kind() {
+		case reflect.Struct:
+			if err := mergeStruct(p, o, c); err != nil {
+				return err
+			}
+		case reflect.Ptr:
+			if sf.Type.Elem().Kind() == reflect.Struct && !p.IsNil() && !o.IsNil() && !c.IsNil() {
+				if err := mergeStruct(p.Elem(), o.Elem(), c.Elem()); err != nil {
+					return err
+				}
+			}
+		case reflect.Map:
+			c.Set(mergeLoadedMap(p, o, c, strategy == MergeReplace))
+		case reflect.Slice:
+			if strategy == MergeAppend || strategy == MergeUnique {
+				c.Set(mergeSlice(p, c, strategy == MergeUnique))
+			}
+		}
+	}
+
+	return nil
+}
+
+// mergeLoadedMap returns the map set by a loader merged with its previous
+// value p. o is the map before the loader ran, which some decoders and
+// loaders change in place, and others replace with a new map. The maps to
+// replace are nil before the loader runs, so they're always new.
+func mergeLoadedMap(p, o, c reflect.Value, replace bool) reflect.Value {
+	if c.IsNil() {
+		// not set by the loader
+		return p
+	}
+
+	if replace {
+		return c
+	}
+
+	if o.IsNil() || c.Pointer() != o.Pointer() {
+		return mergeMap(p, c)
+	}
+
+	// changed in place, the loaded keys are the ones added or changed
+	loaded := reflect.MakeMap(c.Type())
+	for _, key := range c.MapKeys() {
+		if pv := p.MapIndex(key); !pv.IsValid() || !reflect.DeepEqual(pv.Interface(), c.MapIndex(key).Interface()) {
+			loaded.SetMapIndex(key, c.MapIndex(key))
+		}
+	}
+
+	if loaded.Len() == 0 {
+		return c
+	}
+
+	// the values of the loaded keys may replace nested maps, and the keys
+	// removed by the loader stay removed
+	for _, key := range loaded.MapKeys() {
+		if pv := p.MapIndex(key); pv.IsValid() {
+			pm, cm := underlying(pv), underlying(c.MapIndex(key))
+			if pm.Kind() == reflect.Map && cm.Kind() == reflect.Map && pm.Type() == cm.Type() {
+				c.SetMapIndex(key, mergeMap(pm, cm))
+			}
+		}
+	}
+
+	return c
+}
+
+// mergeMap returns the previous map overridden with the loaded keys. Nested
+// maps are merged too.
+func mergeMap(prev, cur reflect.Value) reflect.Value {
+	if prev.Len() == 0 {
+		return cur
+	}
+
+	merged := reflect.MakeMapWithSize(cur.Type(), prev.Len()+cur.Len())
+	for _, key := range prev.MapKeys() {
+		merged.SetMapIndex(key, prev.MapIndex(key))
+	}
+
+	for _, key := range cur.MapKeys() {
+		val := cur.MapIndex(key)
+
+		if p := prev.MapIndex(key); p.IsValid() {
+			pm, cm := underlying(p), underlying(val)
+			if pm.Kind() == reflect.Map && cm.Kind() == reflect.Map && pm.Type() == cm.Type() {
+				val = mergeMap(pm, cm)
+			}
+		}
+
+		merged.SetMapIndex(key, val)
+	}
+
+	return merged
+}
+
+// mergeSlice returns the previous slice with the loaded items appended. If
+// the slice wasn't changed by the loader, it's returned as is.
+func mergeSlice(prev, cur reflect.Value, unique bool) reflect.Value {
+	if prev.Len() == 0 || reflect.DeepEqual(prev.Interface(), cur.Interface()) {
+		return cur
+	}
+
+	merged := reflect.MakeSlice(cur.Type(), 0, prev.Len()+cur.Len())
+
+	for _, s := range []reflect.Value{prev, cur} {
+		for i := 0; i < s.Len(); i++ {
+			item := s.Index(i)
+			if unique && containsValue(merged, item) {
+				continue
+			}
+
+			merged = reflect.Append(merged, item)
+		}
+	}
+
+	return merged
+}
+
+func containsValue(s, item reflect.Value) bool {
+	for i := 0; i < s.Len(); i++ {
+		if reflect.DeepEqual(s.Index(i).Interface(), item.Interface()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// underlying returns the value stored in the interface v.
+func underlying(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	return v
+}
+
+// copyStructs returns a copy of struct v, and of its nested structs, that
+// shares the maps of v.
+func copyStructs(v reflect.Value) reflect.Value {
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).PkgPath != "" {
+			continue
+		}
+
+		switch f := v.Field(i); f.Kind() {
+		case reflect.Struct:
+			c.Field(i).Set(copyStructs(f))
+		case reflect.Ptr:
+			if !f.IsNil() && f.Elem().Kind() == reflect.Struct {
+				p := reflect.New(f.Type().Elem())
+				p.Elem().Set(copyStructs(f.Elem()))
+				c.Field(i).Set(p)
+			}
+		}
+	}
+
+	return c
+}
+
+// deepCopy returns a copy of v that doesn't share any maps, slices or
+// pointers with v, apart from the ones of unexported fields.
+func deepCopy(v reflect.Value) reflect.Value {
+	c := reflect.New(v.Type()).Elem()
+
+	switch v.Kind() {
+	case reflect.Struct:
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				c.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			p := reflect.New(v.Type().Elem())
+			p.Elem().Set(deepCopy(v.Elem()))
+			c.Set(p)
+		}
+	case reflect.Map:
+		if !v.IsNil() {
+			m := reflect.MakeMapWithSize(v.Type(), v.Len())
+			for _, key := range v.MapKeys() {
+				m.SetMapIndex(key, deepCopy(v.MapIndex(key)))
+			}
+			c.Set(m)
+		}
+	case reflect.Slice:
+		if !v.IsNil() {
+			s := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+			for i := 0; i < v.Len(); i++ {
+				s.Index(i).Set(deepCopy(v.Index(i)))
+			}
+			c.Set(s)
+		}
+	case reflect.Interface:
+		if !v.IsNil() {
+			c.Set(deepCopy(v.Elem()))
+		}
+	default:
+		c.Set(v)
+	}
+
+	return c
+}
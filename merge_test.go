@@ -0,0 +1,247 @@
+package multiconfig
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type MergeConfig struct {
+	Hosts   []string
+	Users   []string `merge:"append"`
+	Tags    []string `merge:"unique"`
+	Labels  map[string]string
+	Limits  map[string]int `merge:"replace"`
+	Options map[string]interface{}
+	Nested  struct {
+		Users []string `merge:"append"`
+	}
+}
+
+func TestMerge(t *testing.T) {
+	toml := `
+hosts = ["a"]
+users = ["ankara"]
+tags = ["x", "y"]
+
+[labels]
+env = "dev"
+team = "core"
+
+[limits]
+cpu = 1
+
+[options]
+debug = true
+
+[options.db]
+host = "localhost"
+port = 5432
+
+[nested]
+users = ["a"]
+`
+
+	json := `{
+	"hosts": ["b"],
+	"users": ["istanbul"],
+	"tags": ["y", "z"],
+	"labels": {"env": "prod"},
+	"limits": {"mem": 2},
+	"options": {"db": {"port": 6543}},
+	"nested": {"users": ["b"]}
+}`
+
+	m := MultiLoader(
+		&TOMLLoader{Reader: strings.NewReader(toml)},
+		&JSONLoader{Reader: strings.NewReader(json)},
+	)
+
+	s := &MergeConfig{}
+	if err := m.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"b"}; !reflect.DeepEqual(s.Hosts, want) {
+		t.Errorf("Hosts value is wrong: %v, want: %v", s.Hosts, want)
+	}
+
+	if want := []string{"ankara", "istanbul"}; !reflect.DeepEqual(s.Users, want) {
+		t.Errorf("Users value is wrong: %v, want: %v", s.Users, want)
+	}
+
+	if want := []string{"x", "y", "z"}; !reflect.DeepEqual(s.Tags, want) {
+		t.Errorf("Tags value is wrong: %v, want: %v", s.Tags, want)
+	}
+
+	if want := map[string]string{"env": "prod", "team": "core"}; !reflect.DeepEqual(s.Labels, want) {
+		t.Errorf("Labels value is wrong: %v, want: %v", s.Labels, want)
+	}
+
+	if want := map[string]int{"mem": 2}; !reflect.DeepEqual(s.Limits, want) {
+		t.Errorf("Limits value is wrong: %v, want: %v", s.Limits, want)
+	}
+
+	if s.Options["debug"] != true {
+		t.Errorf("Options debug value is wrong: %v, want: %v", s.Options["debug"], true)
+	}
+
+	db, _ := s.Options["db"].(map[string]interface{})
+	if db["host"] != "localhost" || db["port"] != float64(6543) {
+		t.Errorf("Options db value is wrong: %v", db)
+	}
+
+	if want := []string{"a", "b"}; !reflect.DeepEqual(s.Nested.Users, want) {
+		t.Errorf("Nested.Users value is wrong: %v, want: %v", s.Nested.Users, want)
+	}
+}
+
+func TestMergeUnchanged(t *testing.T) {
+	m := MultiLoader(
+		&TagLoader{},
+		&JSONLoader{Reader: strings.NewReader(`{"users": ["ankara"]}`)},
+		&JSONLoader{Reader: strings.NewReader(`{"hosts": ["a"]}`)},
+	)
+
+	s := &MergeConfig{}
+	if err := m.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"ankara"}; !reflect.DeepEqual(s.Users, want) {
+		t.Errorf("Users value is wrong: %v, want: %v", s.Users, want)
+	}
+}
+
+func TestMergeNestedMultiLoader(t *testing.T) {
+	m := MultiLoader(
+		&JSONLoader{Reader: strings.NewReader(`{"users": ["ankara"]}`)},
+		MultiLoader(
+			&JSONLoader{Reader: strings.NewReader(`{"users": ["istanbul"]}`)},
+			&JSONLoader{Reader: strings.NewReader(`{"users": ["izmir"]}`)},
+		),
+	)
+
+	s := &MergeConfig{}
+	if err := m.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"ankara", "istanbul", "izmir"}; !reflect.DeepEqual(s.Users, want) {
+		t.Errorf("Users value is wrong: %v, want: %v", s.Users, want)
+	}
+}
+
+func TestMergeUnknownStrategy(t *testing.T) {
+	s := &struct {
+		Users []string `merge:"prepend"`
+	}{}
+
+	m := MultiLoader(&JSONLoader{Reader: strings.NewReader(`{"users": ["ankara"]}`)})
+
+	var ferr *FieldError
+	if err := m.Load(s); !errors.As(err, &ferr) || ferr.Field != "Users" {
+		t.Fatalf("error should be a *FieldError for Users, got: %v", err)
+	}
+}
+
+// labelLoader adds a label to the existing Labels map.
+type labelLoader struct{}
+
+func (labelLoader) Load(s interface{}) error {
+	c := s.(*MergeConfig)
+	c.Labels["b"] = "2"
+	return nil
+}
+
+func TestMergeMapChangedInPlace(t *testing.T) {
+	s := &MergeConfig{Labels: map[string]string{"a": "1"}}
+
+	m := MultiLoader(
+		labelLoader{},
+		&JSONLoader{Reader: strings.NewReader(`{"labels": {"c": "3"}}`)},
+	)
+
+	if err := m.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := map[string]string{"a": "1", "b": "2", "c": "3"}; !reflect.DeepEqual(s.Labels, want) {
+		t.Errorf("Labels value is wrong: %v, want: %v", s.Labels, want)
+	}
+}
+
+func TestMergeMapReplace(t *testing.T) {
+	tests := []struct {
+		name    string
+		loaders []Loader
+		want    map[string]int
+	}{
+		{
+			name: "same value",
+			loaders: []Loader{
+				&JSONLoader{Reader: strings.NewReader(`{"limits": {"cpu": 1, "mem": 2}}`)},
+				&JSONLoader{Reader: strings.NewReader(`{"limits": {"cpu": 1, "disk": 3}}`)},
+			},
+			want: map[string]int{"cpu": 1, "disk": 3},
+		},
+		{
+			name: "omitted key",
+			loaders: []Loader{
+				&YAMLLoader{Reader: strings.NewReader("limits: {cpu: 1, mem: 2}\n")},
+				&TOMLLoader{Reader: strings.NewReader("[limits]\ncpu = 1\n")},
+			},
+			want: map[string]int{"cpu": 1},
+		},
+		{
+			name: "not set",
+			loaders: []Loader{
+				&JSONLoader{Reader: strings.NewReader(`{"limits": {"cpu": 1}}`)},
+				&JSONLoader{Reader: strings.NewReader(`{"hosts": ["a"]}`)},
+			},
+			want: map[string]int{"cpu": 1},
+		},
+	}
+
+	for _, test := range tests {
+		s := &MergeConfig{}
+		if err := MultiLoader(test.loaders...).Load(s); err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(s.Limits, test.want) {
+			t.Errorf("%s: Limits value is wrong: %v, want: %v", test.name, s.Limits, test.want)
+		}
+	}
+}
+
+// upperUsersLoader uppercases the previously loaded users.
+type upperUsersLoader struct{}
+
+func (upperUsersLoader) Load(s interface{}) error {
+	c := s.(*MergeConfig)
+	for i, u := range c.Users {
+		c.Users[i] = strings.ToUpper(u)
+	}
+	return nil
+}
+
+func (upperUsersLoader) SkipMerge() bool { return true }
+
+func TestMergeSkipper(t *testing.T) {
+	m := MultiLoader(
+		&JSONLoader{Reader: strings.NewReader(`{"users": ["a"]}`)},
+		upperUsersLoader{},
+	)
+
+	s := &MergeConfig{}
+	if err := m.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"A"}; !reflect.DeepEqual(s.Users, want) {
+		t.Errorf("Users value is wrong: %v, want: %v", s.Users, want)
+	}
+}
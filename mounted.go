@@ -0,0 +1,120 @@
+package multiconfig
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/structs"
+)
+
+// MountedFilesLoader satisifies the loader interface. It loads the
+// configuration from a directory with a file per key, like the Kubernetes
+// Secrets and ConfigMaps mounted as volumes. The content of a file is the
+// value of the key, without its trailing newlines.
+//
+// File names are mapped to fields in the same way as the environment
+// variables of EnvironmentLoader, without the struct name. Nested structs are
+// separated by "_" or by directories, i.e: the Password field of the Postgres
+// struct is loaded from "postgres_password" or "postgres/password". Names are
+// case-insensitive.
+//
+// If the directory has a "..data" symlink, as Kubernetes mounts do, the files
+// are read from its target, so a concurrent update of the volume is either
+// loaded entirely or not at all. Entries starting with ".." are skipped.
+type MountedFilesLoader struct {
+	Path string
+
+	// FS is the file system to read the directory from. If nil, the OS file
+	// system is used. The "..data" directory of an fs.FS is read as is.
+	FS fs.FS
+
+	// CamelCase adds a separator for field names in camelcase form, see
+	// EnvironmentLoader.CamelCase. A fieldname of "DBName" would be loaded
+	// from "db_name" instead of "dbname".
+	CamelCase bool
+
+	// Optional skips the directory if it doesn't exist, instead of returning
+	// ErrFileNotFound.
+	Optional bool
+}
+
+// Load loads the files of the directory into the config defined by struct s.
+func (m *MountedFilesLoader) Load(s interface{}) error {
+	if m.Path == "" {
+		return ErrSourceNotSet
+	}
+
+	fsys := fileSystem{m.FS}
+
+	dir := m.Path
+	if _, err := fsys.stat(dir); os.IsNotExist(err) {
+		if m.Optional {
+			return nil
+		}
+		return ErrFileNotFound
+	}
+
+	// read from a single version of the volume
+	data := fsys.join(dir, "..data")
+	if m.FS == nil {
+		if target, err := filepath.EvalSymlinks(data); err == nil {
+			dir = target
+		}
+	} else if info, err := fsys.stat(data); err == nil && info.IsDir() {
+		dir = data
+	}
+
+	e := &EnvironmentLoader{CamelCase: m.CamelCase}
+	prefix := strings.ToUpper(e.getPrefix(structs.New(s))) + "_"
+
+	vars := make(map[string]string)
+	if err := readMountedFiles(fsys, dir, prefix, vars); err != nil {
+		return err
+	}
+
+	e.getenv = func(key string) string { return vars[key] }
+	return e.Load(s)
+}
+
+// readMountedFiles reads the files of dir and its subdirectories into vars,
+// keyed by their environment variable names.
+func readMountedFiles(fsys fileSystem, dir, prefix string, vars map[string]string) error {
+	entries, err := fsys.readDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, "..") {
+			continue
+		}
+
+		path := fsys.join(dir, name)
+
+		info, err := fsys.stat(path)
+		if err != nil {
+			return err
+		}
+
+		key := prefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+
+		if info.IsDir() {
+			if err := readMountedFiles(fsys, path, key+"_", vars); err != nil {
+				return err
+			}
+			continue
+		}
+
+		data, err := fsys.readFile(path)
+		if err != nil {
+			return err
+		}
+
+		vars[key] = strings.TrimRight(string(data), "\r\n")
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package multiconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type MountedConfig struct {
+	Name     string
+	Port     int
+	Postgres struct {
+		Password string
+		DBName   string
+	}
+}
+
+func TestMountedFilesLoader(t *testing.T) {
+	s := &MountedConfig{}
+	if err := (&MountedFilesLoader{Path: testSecrets}).Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Name != "koding" {
+		t.Errorf("Name value is wrong: %q, want: %q", s.Name, "koding")
+	}
+
+	// the previous version of the volume must be ignored
+	if s.Port != 7070 {
+		t.Errorf("Port value is wrong: %d, want: %d", s.Port, 7070)
+	}
+
+	if s.Postgres.Password != "s3cr3t" {
+		t.Errorf("Postgres.Password value is wrong: %q, want: %q", s.Postgres.Password, "s3cr3t")
+	}
+
+	if s.Postgres.DBName != "configdb" {
+		t.Errorf("Postgres.DBName value is wrong: %q, want: %q", s.Postgres.DBName, "configdb")
+	}
+}
+
+func TestMountedFilesLoaderCamelCase(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.Mkdir(filepath.Join(dir, "postgres"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"port":             "6060",
+		"postgres/db_name": "configdb\n",
+		".hidden":          "ignored",
+	}
+
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := &MountedConfig{}
+	if err := (&MountedFilesLoader{Path: dir, CamelCase: true}).Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Port != 6060 {
+		t.Errorf("Port value is wrong: %d, want: %d", s.Port, 6060)
+	}
+
+	if s.Postgres.DBName != "configdb" {
+		t.Errorf("Postgres.DBName value is wrong: %q, want: %q", s.Postgres.DBName, "configdb")
+	}
+}
+
+func TestMountedFilesLoaderNotFound(t *testing.T) {
+	m := &MountedFilesLoader{Path: "testdata/missing"}
+	if err := m.Load(&MountedConfig{}); err != ErrFileNotFound {
+		t.Errorf("error should be ErrFileNotFound, got: %v", err)
+	}
+
+	m.Optional = true
+	if err := m.Load(&MountedConfig{}); err != nil {
+		t.Errorf("optional directory should be skipped, got: %v", err)
+	}
+}
@@ -1,8 +1,10 @@
 package multiconfig
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"io/fs"
 	"os"
 	"reflect"
 	"strconv"
@@ -19,6 +21,50 @@ type Loader interface {
 	Load(s interface{}) error
 }
 
+// ContextLoader is implemented by loaders that can be canceled, i.e: loaders
+// reading from a slow remote source.
+type ContextLoader interface {
+	// LoadContext loads the source into the config defined by struct s. It
+	// stops and returns the context's error if ctx is done before loading
+	// finishes.
+	LoadContext(ctx context.Context, s interface{}) error
+}
+
+// MergeSkipper is implemented by loaders whose values MultiLoader must not
+// merge with the values of the previous loaders, i.e: loaders merging the
+// values of the loaders they execute themselves, or transforming the values
+// loaded by the previous loaders instead of loading new ones. Without it, a
+// loader changing the items of a slice tagged with merge:"append" would
+// append them to the previous items.
+type MergeSkipper interface {
+	// SkipMerge reports whether the values set by the loader must not be
+	// merged.
+	SkipMerge() bool
+}
+
+// WithContext returns a ContextLoader for the given loader. If l doesn't
+// implement ContextLoader, the returned loader checks the context before
+// calling l.Load.
+func WithContext(l Loader) ContextLoader {
+	if cl, ok := l.(ContextLoader); ok {
+		return cl
+	}
+
+	return contextLoader{l}
+}
+
+type contextLoader struct {
+	Loader
+}
+
+func (c contextLoader) LoadContext(ctx context.Context, s interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return c.Load(s)
+}
+
 // DefaultLoader implements the Loader interface. It initializes the given
 // pointer of struct s with configuration from the default sources. The order
 // of load is TagLoader, FileLoader, EnvLoader and lastly FlagLoader. An error
@@ -31,24 +77,41 @@ type DefaultLoader struct {
 }
 
 // NewWithPath returns a new instance of Loader to read from the given
-// configuration file.
+// configuration file. The file's format is chosen by its extension or, if
+// the extension is unknown, by its content. See FileLoader.
 func NewWithPath(path string) *DefaultLoader {
+	return NewWithPaths(path)
+}
+
+// NewWithPaths returns a new instance of Loader to read from the given
+// configuration files. The files are loaded in order, each one overriding the
+// previous ones, before the environment variables and flags. The files may be
+// in different formats. Use FileLoader with Optional to load files that may
+// not exist.
+func NewWithPaths(paths ...string) *DefaultLoader {
+	return newWithFS(nil, paths)
+}
+
+// NewWithFS returns a new instance of Loader like NewWithPaths, reading the
+// configuration files from the given file system, i.e: an embed.FS with the
+// default configuration.
+func NewWithFS(fsys fs.FS, paths ...string) *DefaultLoader {
+	return newWithFS(fsys, paths)
+}
+
+// newWithFS returns the Loader of NewWithPaths, reading the files from fsys,
+// or from the OS file system if fsys is nil.
+func newWithFS(fsys fs.FS, paths []string) *DefaultLoader {
 	loaders := []Loader{}
 
 	// Read default values defined via tag fields "default"
 	loaders = append(loaders, &TagLoader{})
 
-	// Choose what while is passed
-	if strings.HasSuffix(path, "toml") {
-		loaders = append(loaders, &TOMLLoader{Path: path})
-	}
-
-	if strings.HasSuffix(path, "json") {
-		loaders = append(loaders, &JSONLoader{Path: path})
-	}
-
-	if strings.HasSuffix(path, "yml") || strings.HasSuffix(path, "yaml") {
-		loaders = append(loaders, &YAMLLoader{Path: path})
+	// Read the files in the format of their extension or content
+	for _, path := range paths {
+		if path != "" {
+			loaders = append(loaders, &FileLoader{Path: path, FS: fsys})
+		}
 	}
 
 	e := &EnvironmentLoader{}
@@ -63,6 +126,43 @@ func NewWithPath(path string) *DefaultLoader {
 	return d
 }
 
+// NewWithConfigFlag returns a new instance of Loader like NewWithPaths. The
+// path of the configuration file may also be given with the flag and the
+// environment variable of the given names, i.e: "config" and "APP_CONFIG",
+// which replace the given paths. The flag is shown in the usage of the flags.
+// An empty name disables the flag or the environment variable.
+func NewWithConfigFlag(flagName, envName string, paths ...string) *DefaultLoader {
+	loader := MultiLoader(
+		&TagLoader{},
+		&ConfigPathLoader{Flag: flagName, Env: envName, Paths: paths},
+		&EnvironmentLoader{},
+		&FlagLoader{ConfigFlag: flagName, ConfigEnv: envName},
+	)
+
+	d := &DefaultLoader{}
+	d.Loader = loader
+	d.Validator = MultiValidator(&RequiredValidator{})
+	return d
+}
+
+// NewWithProfile returns a new instance of Loader like NewWithPath. The
+// profile selected with the flag or the environment variable of the given
+// names, i.e: "profile" and "APP_PROFILE", is loaded over the file, before
+// the environment variables and flags. See ProfileLoader.
+func NewWithProfile(path, flagName, envName string) *DefaultLoader {
+	loader := MultiLoader(
+		&TagLoader{},
+		&ProfileLoader{Path: path, Flag: flagName, Env: envName},
+		&EnvironmentLoader{},
+		&FlagLoader{ProfileFlag: flagName, ProfileEnv: envName},
+	)
+
+	d := &DefaultLoader{}
+	d.Loader = loader
+	d.Validator = MultiValidator(&RequiredValidator{})
+	return d
+}
+
 // New returns a new instance of DefaultLoader without any file loaders.
 func New() *DefaultLoader {
 	loader := MultiLoader(
@@ -91,6 +191,18 @@ func MustLoad(conf interface{}) {
 	d.MustLoad(conf)
 }
 
+// LoadContext is like Load but stops loading if ctx is done. The loaders are
+// given the context if they implement ContextLoader.
+func (d *DefaultLoader) LoadContext(ctx context.Context, conf interface{}) error {
+	return WithContext(d.Loader).LoadContext(ctx, conf)
+}
+
+// SkipMerge implements the MergeSkipper interface. It reports whether the
+// wrapped loader skips merging.
+func (d *DefaultLoader) SkipMerge() bool {
+	return skipMerge(d.Loader)
+}
+
 // MustLoad is like Load but panics if the config cannot be parsed.
 func (d *DefaultLoader) MustLoad(conf interface{}) {
 	if err := d.Load(conf); err != nil {
@@ -117,8 +229,28 @@ func (d *DefaultLoader) MustValidate(conf interface{}) {
 
 // fieldSet sets field value from the given string value. It converts the
 // string value in a sane way and is usefulf or environment variables or flags
-// which are by nature in string types.
-func fieldSet(field *structs.Field, v string) error {
+// which are by nature in string types. path is the path of the field,
+// returned in the *FieldError.
+func fieldSet(field *structs.Field, path, v string) error {
+	if err := setField(field, v); err != nil {
+		return &FieldError{Field: path, Value: v, Err: err}
+	}
+
+	return nil
+}
+
+// fieldPath returns the path of the field with the given name in the struct
+// at path. The fields of nested structs are separated by dots, i.e:
+// Postgres.Port.
+func fieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+func setField(field *structs.Field, v string) error {
 	switch f := field.Value().(type) {
 	case flag.Value:
 		if v := reflect.ValueOf(field.Value()); v.IsNil() {
@@ -182,8 +314,7 @@ func fieldSet(field *structs.Field, v string) error {
 				return err
 			}
 		default:
-			return fmt.Errorf("multiconfig: field '%s' of type slice is unsupported: %s (%T)",
-				field.Name(), field.Kind(), t)
+			return fmt.Errorf("%w: %s (%T)", ErrUnsupportedType, field.Kind(), t)
 		}
 	case reflect.Float64:
 		f, err := strconv.ParseFloat(v, 64)
@@ -215,12 +346,11 @@ func fieldSet(field *structs.Field, v string) error {
 				return err
 			}
 		default:
-			return fmt.Errorf("multiconfig: field '%s' of type int64 is unsupported: %s (%T)",
-				field.Name(), field.Kind(), t)
+			return fmt.Errorf("%w: %s (%T)", ErrUnsupportedType, field.Kind(), t)
 		}
 
 	default:
-		return fmt.Errorf("multiconfig: field '%s' has unsupported type: %s", field.Name(), field.Kind())
+		return fmt.Errorf("%w: %s", ErrUnsupportedType, field.Kind())
 	}
 
 	return nil
@@ -1,6 +1,8 @@
 package multiconfig
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 )
@@ -46,9 +48,21 @@ type CamelCaseServer struct {
 }
 
 var (
-	testTOML = "testdata/config.toml"
-	testJSON = "testdata/config.json"
-	testYAML = "testdata/config.yaml"
+	testTOML  = "testdata/config.toml"
+	testJSON  = "testdata/config.json"
+	testJSON5 = "testdata/config.json5"
+	testYAML  = "testdata/config.yaml"
+	testHCL   = "testdata/config.hcl"
+	testINI   = "testdata/config.ini"
+	testXML   = "testdata/config.xml"
+	testEnv   = "testdata/config.env"
+
+	testProperties = "testdata/config.properties"
+	testDir        = "testdata/conf.d"
+	testSecrets    = "testdata/secrets"
+	testInclude    = "testdata/include"
+	testProfile    = "testdata/profile"
+	testSOPS       = "testdata/sops"
 )
 
 func getDefaultServer() *Server {
@@ -113,6 +127,90 @@ func TestDefaultLoader(t *testing.T) {
 	}
 }
 
+func TestNewWithPaths(t *testing.T) {
+	type PathsConfig struct {
+		Name  string
+		Port  int `default:"6060"`
+		Users []string
+	}
+
+	m := NewWithPaths(testTOML, "testdata/override.yaml")
+
+	s := new(PathsConfig)
+	if err := m.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Name != "koding" {
+		t.Errorf("Name value is wrong: %s, want: %s", s.Name, "koding")
+	}
+
+	if s.Port != 7070 {
+		t.Errorf("Port value is wrong: %d, want: %d", s.Port, 7070)
+	}
+
+	if len(s.Users) != 1 || s.Users[0] != "gopher" {
+		t.Errorf("Users value is wrong: %v, want: %v", s.Users, []string{"gopher"})
+	}
+
+	m = NewWithPaths(testTOML, "testdata/missing.toml")
+	if err := m.Load(s); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("error should be ErrFileNotFound, got: %v", err)
+	}
+}
+
+func TestOptionalFile(t *testing.T) {
+	l := MultiLoader(
+		&FileLoader{Path: testTOML},
+		&FileLoader{Path: "testdata/missing.toml", Optional: true},
+	)
+
+	s := new(Server)
+	if err := l.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Name != "koding" {
+		t.Errorf("Name value is wrong: %s, want: %s", s.Name, "koding")
+	}
+}
+
+// cancelLoader cancels the context it's given to emulate a slow loader whose
+// deadline exceeds.
+type cancelLoader struct {
+	cancel context.CancelFunc
+}
+
+func (c *cancelLoader) Load(s interface{}) error {
+	c.cancel()
+	return nil
+}
+
+func TestLoadContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := &DefaultLoader{
+		Loader: MultiLoader(&cancelLoader{cancel: cancel}, &TagLoader{}),
+	}
+
+	s := new(Server)
+	err := d.LoadContext(ctx, s)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error should be context.Canceled, got: %v", err)
+	}
+
+	if s.Port != 0 {
+		t.Errorf("TagLoader should not run after cancel, Port is: %d", s.Port)
+	}
+
+	if err := NewWithPath(testTOML).LoadContext(context.Background(), s); err != nil {
+		t.Error(err)
+	}
+
+	testStruct(t, s, getDefaultServer())
+}
+
 func testStruct(t *testing.T, s *Server, d *Server) {
 	if s.Name != d.Name {
 		t.Errorf("Name value is wrong: %s, want: %s", s.Name, d.Name)
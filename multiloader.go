@@ -1,17 +1,59 @@
 package multiconfig
 
+import (
+	"context"
+	"errors"
+)
+
 type multiLoader []Loader
 
 // MultiLoader creates a loader that executes the loaders one by one in order
-// and returns on the first error.
+// and returns on the first error. The error is wrapped in a *LoaderError
+// naming the loader that failed.
+//
+// The maps set by a loader are merged by key with the ones set by the
+// previous loaders. Slices are replaced, unless the field is tagged with
+// merge:"append" or merge:"unique". The values of the loaders implementing
+// MergeSkipper aren't merged.
 func MultiLoader(loader ...Loader) Loader {
 	return multiLoader(loader)
 }
 
 // Load loads the source into the config defined by struct s
 func (m multiLoader) Load(s interface{}) error {
+	return m.LoadContext(context.Background(), s)
+}
+
+// SkipMerge implements the MergeSkipper interface. It returns true, as the
+// loaders are merged one by one.
+func (m multiLoader) SkipMerge() bool {
+	return true
+}
+
+// LoadContext loads the source into the config defined by struct s. The
+// context is passed to the loaders implementing ContextLoader, and loading
+// stops before the next loader if ctx is done.
+func (m multiLoader) LoadContext(ctx context.Context, s interface{}) error {
 	for _, loader := range m {
-		if err := loader.Load(s); err != nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var prev *loadSnapshot
+		if !skipMerge(loader) {
+			prev = snapshot(s)
+		}
+
+		if err := WithContext(loader).LoadContext(ctx, s); err != nil {
+			var lerr *LoaderError
+			if errors.As(err, &lerr) {
+				return err
+			}
+
+			return &LoaderError{Loader: loaderName(loader), Err: err}
+		}
+
+		if err := mergeLoaded(prev, s); err != nil {
 			return err
 		}
 	}
@@ -19,6 +61,13 @@ func (m multiLoader) Load(s interface{}) error {
 	return nil
 }
 
+// skipMerge reports whether the values set by l must not be merged with the
+// previous values. See MergeSkipper.
+func skipMerge(l Loader) bool {
+	ms, ok := l.(MergeSkipper)
+	return ok && ms.SkipMerge()
+}
+
 // MustLoad loads the source into the struct, it panics if gets any error
 func (m multiLoader) MustLoad(s interface{}) {
 	if err := m.Load(s); err != nil {
@@ -0,0 +1,159 @@
+package multiconfig
+
+import (
+	"bytes"
+	"io/fs"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// profileTags are the struct tags naming the profile in the profile sections
+// of a config file, for the formats that decode into structs.
+var profileTags = []string{"toml", "json", "yaml", "hcl", "ini", "xml"}
+
+// ProfileLoader satisifies the loader interface. It loads the given config
+// file and then the overlays of the selected profile, i.e: dev, staging or
+// prod. For the "staging" profile of "config.toml", the keys of the
+// [profiles.staging] section of the file are loaded first, and then the
+// "config.staging.toml" file, if it exists:
+//
+//	port = 6060
+//
+//	[profiles.staging]
+//	port = 7070
+//
+// The profile is given with Profile, or with a flag or an environment
+// variable, i.e: -profile or APP_PROFILE. The flag takes precedence over the
+// environment variable. The flag must also be defined with
+// FlagLoader.ProfileFlag so it's accepted and shown in the usage. Profile
+// sections are supported in toml, json, yaml, hcl, ini and xml files.
+type ProfileLoader struct {
+	// Path is the path of the base config file.
+	Path string
+
+	// Profile is the selected profile. If empty, the profile is read from
+	// the flag or the environment variable.
+	Profile string
+
+	// Flag is the name of the flag, i.e: "profile" for -profile or --profile.
+	Flag string
+
+	// Env is the name of the environment variable, i.e: "APP_PROFILE".
+	Env string
+
+	// Args defines a custom argument list. If nil, os.Args[1:] is used.
+	Args []string
+
+	// FS is the file system to read the config files from, see FileLoader.
+	FS fs.FS
+}
+
+// Load loads the config file and the overlays of the profile into the config
+// defined by struct s. Maps and slices are merged as in MultiLoader.
+func (p *ProfileLoader) Load(s interface{}) error {
+	if p.Path == "" {
+		return ErrSourceNotSet
+	}
+
+	loaders := []Loader{&FileLoader{Path: p.Path, FS: p.FS}}
+
+	if profile := p.profile(); profile != "" {
+		loaders = append(loaders,
+			&profileSectionLoader{path: p.Path, profile: profile, fsys: p.FS},
+			&FileLoader{Path: profilePath(p.Path, profile), FS: p.FS, Optional: true},
+		)
+	}
+
+	return multiLoader(loaders).Load(s)
+}
+
+// SkipMerge implements the MergeSkipper interface. It returns true, as the
+// overlays are merged by the MultiLoader it executes.
+func (p *ProfileLoader) SkipMerge() bool {
+	return true
+}
+
+// profile returns the selected profile.
+func (p *ProfileLoader) profile() string {
+	if p.Profile != "" {
+		return p.Profile
+	}
+
+	return flagOrEnv(p.Args, p.Flag, p.Env)
+}
+
+// profilePath returns the path of the overlay file of the given profile,
+// i.e: "config.staging.toml" for "config.toml".
+func profilePath(path, profile string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + profile + ext
+}
+
+// profileSectionLoader loads the section of the given profile of a config
+// file.
+type profileSectionLoader struct {
+	path    string
+	profile string
+	fsys    fs.FS
+}
+
+// Load loads the profile section into the config defined by struct s. The
+// file is decoded into a struct with the profile section, initialized with
+// the current config, which is then copied back to s.
+func (p *profileSectionLoader) Load(s interface{}) error {
+	file, path, err := fileSystem{p.fsys}.open(p.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return err
+	}
+
+	format, err := (&FileLoader{FS: p.fsys}).format(path, data)
+	if err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(s).Elem()
+	section := reflect.New(profilesType(v.Type(), p.profile)).Elem()
+	config := section.Field(0).Field(0)
+	config.Set(v)
+
+	if err := format.NewLoader(bytes.NewReader(data)).Load(section.Addr().Interface()); err != nil {
+		return err
+	}
+
+	v.Set(config)
+	return nil
+}
+
+// profilesType returns the type of a struct with the profiles section, in
+// the form of:
+//
+//	struct {
+//		Profiles struct {
+//			Profile T `toml:"<profile>" json:"<profile>" ...`
+//		}
+//	}
+func profilesType(t reflect.Type, profile string) reflect.Type {
+	var tag strings.Builder
+	for i, name := range profileTags {
+		if i > 0 {
+			tag.WriteByte(' ')
+		}
+		tag.WriteString(name + `:"` + profile + `"`)
+	}
+
+	profiles := reflect.StructOf([]reflect.StructField{
+		{Name: "Profile", Type: t, Tag: reflect.StructTag(tag.String())},
+	})
+
+	return reflect.StructOf([]reflect.StructField{
+		{Name: "Profiles", Type: profiles},
+	})
+}
@@ -0,0 +1,104 @@
+package multiconfig
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProfileLoader(t *testing.T) {
+	path := filepath.Join(testProfile, "config.toml")
+
+	tests := []struct {
+		name   string
+		loader *ProfileLoader
+		env    string
+		port   int
+		dbName string
+		users  []string
+	}{
+		{
+			name:   "no profile",
+			loader: &ProfileLoader{Path: path, Args: []string{}},
+			port:   6060,
+			dbName: "configdb",
+			users:  []string{"ankara"},
+		},
+		{
+			name:   "profile",
+			loader: &ProfileLoader{Path: path, Profile: "staging"},
+			port:   7070,
+			dbName: "stagingdb",
+			users:  []string{"istanbul"},
+		},
+		{
+			name:   "flag",
+			loader: &ProfileLoader{Path: path, Flag: "profile", Env: "TEST_PROFILE", Args: []string{"-profile", "prod"}},
+			env:    "staging",
+			port:   8080,
+			dbName: "configdb",
+			users:  []string{"ankara"},
+		},
+		{
+			name:   "env",
+			loader: &ProfileLoader{Path: path, Flag: "profile", Env: "TEST_PROFILE", Args: []string{}},
+			env:    "staging",
+			port:   7070,
+			dbName: "stagingdb",
+			users:  []string{"istanbul"},
+		},
+		{
+			name:   "unknown profile",
+			loader: &ProfileLoader{Path: path, Profile: "dev"},
+			port:   6060,
+			dbName: "configdb",
+			users:  []string{"ankara"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("TEST_PROFILE", test.env)
+
+			s := &Server{}
+			if err := test.loader.Load(s); err != nil {
+				t.Fatal(err)
+			}
+
+			if s.Name != "koding" {
+				t.Errorf("Name value is wrong: %s, want: %s", s.Name, "koding")
+			}
+
+			if s.Port != test.port {
+				t.Errorf("Port value is wrong: %d, want: %d", s.Port, test.port)
+			}
+
+			if s.Postgres.DBName != test.dbName {
+				t.Errorf("Postgres.DBName value is wrong: %s, want: %s", s.Postgres.DBName, test.dbName)
+			}
+
+			if !reflect.DeepEqual(s.Users, test.users) {
+				t.Errorf("Users value is wrong: %v, want: %v", s.Users, test.users)
+			}
+		})
+	}
+}
+
+func TestProfileLoaderYAML(t *testing.T) {
+	p := &ProfileLoader{Path: filepath.Join(testProfile, "config.yaml"), Profile: "staging"}
+
+	s := &Server{}
+	if err := p.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Port != 7070 {
+		t.Errorf("Port value is wrong: %d, want: %d", s.Port, 7070)
+	}
+}
+
+func TestProfilePath(t *testing.T) {
+	if path := profilePath("conf/config.toml", "staging"); path != "conf/config.staging.toml" {
+		t.Errorf("path is wrong: %s, want: %s", path, "conf/config.staging.toml")
+	}
+}
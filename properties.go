@@ -0,0 +1,282 @@
+package multiconfig
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/fatih/camelcase"
+	"github.com/fatih/structs"
+)
+
+// PropertiesLoader satisifies the loader interface. It loads the
+// configuration from the given Java style .properties file or Reader. Keys
+// are the lowercased field names, the fields of nested structs are separated
+// by dots, i.e: "postgres.dbname". Keys are case-insensitive. Values are
+// converted in the same way as environment variables and flags.
+type PropertiesLoader struct {
+	Path   string
+	Reader io.Reader
+	FS     fs.FS
+
+	// CamelCase adds a separator for field names in camelcase form. A
+	// fieldname of "DBName" would generate a key "dbname". If CamelCase is
+	// enabled, the key will be generated in the form of "db.name".
+	CamelCase bool
+
+	// DisallowUnknownKeys causes Load to return an *UnknownKeyError if the
+	// source contains a key that doesn't match any field of the struct.
+	DisallowUnknownKeys bool
+}
+
+// Load loads the source into the config defined by struct s.
+// Defaults to using the Reader if provided, otherwise tries to read from the
+// file
+func (p *PropertiesLoader) Load(s interface{}) error {
+	var r io.Reader
+
+	if p.Reader != nil {
+		r = p.Reader
+	} else if p.Path != "" {
+		file, _, err := fileSystem{p.FS}.open(p.Path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	} else {
+		return ErrSourceNotSet
+	}
+
+	values, err := parseProperties(p.Path, r)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range structs.Fields(s) {
+		if err := p.processField("", "", field, values); err != nil {
+			return err
+		}
+	}
+
+	if p.DisallowUnknownKeys {
+		return checkUnusedKeys(values)
+	}
+
+	return nil
+}
+
+// processField generates the key of the given field in the same way the
+// FlagLoader generates flag names, with dots instead of dashes, and sets the
+// field's value. path is the path of the struct of the field.
+func (p *PropertiesLoader) processField(prefix, path string, field *structs.Field, values map[string]*keyValue) error {
+	if !field.IsExported() {
+		return nil
+	}
+
+	name := field.Name()
+	if p.CamelCase {
+		name = strings.Join(camelcase.Split(name), ".")
+		name = strings.Replace(name, "...", ".", -1)
+	}
+
+	path = fieldPath(path, field.Name())
+
+	key := strings.ToLower(name)
+	if prefix != "" {
+		key = prefix + "." + key
+	}
+
+	switch field.Kind() {
+	case reflect.Struct:
+		for _, f := range field.Fields() {
+			if err := p.processField(key, path, f, values); err != nil {
+				return err
+			}
+		}
+	default:
+		v, ok := values[key]
+		if !ok {
+			return nil
+		}
+		v.used = true
+
+		if err := fieldSet(field, path, v.value); err != nil {
+			return &ParseError{Path: p.Path, Line: v.line, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// parseProperties parses the properties content of r, path is only used for
+// errors. The returned values are keyed by the lowercased keys.
+func parseProperties(path string, r io.Reader) (map[string]*keyValue, error) {
+	values := make(map[string]*keyValue)
+
+	var logical strings.Builder
+	start, continued := 0, false
+
+	add := func() error {
+		key, value, err := splitProperty(logical.String())
+		logical.Reset()
+		if err != nil {
+			return &ParseError{Path: path, Line: start, Err: err}
+		}
+
+		values[strings.ToLower(key)] = &keyValue{key: key, value: value, line: start}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimLeft(scanner.Text(), " \t\f")
+
+		if !continued {
+			if text == "" || text[0] == '#' || text[0] == '!' {
+				continue
+			}
+			start = line
+		}
+
+		// an odd number of trailing backslashes continues the line
+		n := len(text) - len(strings.TrimRight(text, "\\"))
+		continued = n%2 == 1
+		if continued {
+			text = text[:len(text)-1]
+		}
+
+		logical.WriteString(text)
+		if continued {
+			continue
+		}
+
+		if err := add(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if continued {
+		if err := add(); err != nil {
+			return nil, err
+		}
+	}
+
+	return values, nil
+}
+
+// splitProperty splits the logical line s into its unescaped key and value.
+// The key is terminated by the first unescaped '=', ':' or whitespace.
+func splitProperty(s string) (string, string, error) {
+	i := 0
+	for ; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+
+		if strings.IndexByte("=: \t\f", s[i]) >= 0 {
+			break
+		}
+	}
+
+	if i > len(s) {
+		i = len(s)
+	}
+
+	key, err := unescapeProperty(s[:i])
+	if err != nil {
+		return "", "", err
+	}
+
+	rest := strings.TrimLeft(s[i:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+
+	value, err := unescapeProperty(rest)
+	if err != nil {
+		return "", "", err
+	}
+
+	return key, value, nil
+}
+
+// unescapeProperty replaces the escape sequences of s, including \uXXXX
+// unicode escapes.
+func unescapeProperty(s string) (string, error) {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		if i == len(s) {
+			break
+		}
+
+		switch c := s[i]; c {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			r, n, err := unicodeEscape(s[i+1:])
+			if err != nil {
+				return "", err
+			}
+
+			b.WriteRune(r)
+			i += n
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// unicodeEscape decodes the hex digits of a \uXXXX escape at the start of s,
+// combining a following escaped low surrogate if needed. It returns the rune
+// and the number of bytes consumed.
+func unicodeEscape(s string) (rune, int, error) {
+	if len(s) < 4 {
+		return 0, 0, fmt.Errorf("malformed \\u escape: %q", s)
+	}
+
+	v, err := strconv.ParseUint(s[:4], 16, 16)
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed \\u escape: %q", s[:4])
+	}
+
+	r := rune(v)
+	if utf16.IsSurrogate(r) && len(s) >= 10 && s[4:6] == "\\u" {
+		if low, err := strconv.ParseUint(s[6:10], 16, 16); err == nil {
+			if dec := utf16.DecodeRune(r, rune(low)); dec != unicode.ReplacementChar {
+				return dec, 10, nil
+			}
+		}
+	}
+
+	return r, 4, nil
+}
@@ -0,0 +1,124 @@
+package multiconfig
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestProperties(t *testing.T) {
+	m := NewWithPath(testProperties)
+
+	s := &Server{}
+	if err := m.Load(s); err != nil {
+		t.Error(err)
+	}
+
+	testStruct(t, s, getDefaultServer())
+}
+
+func TestProperties_Reader(t *testing.T) {
+	f, err := os.Open(testProperties)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	l := MultiLoader(&TagLoader{}, &PropertiesLoader{Reader: f})
+	s := &Server{}
+	if err := l.Load(s); err != nil {
+		t.Error(err)
+	}
+
+	testStruct(t, s, getDefaultServer())
+}
+
+func TestPropertiesCamelCase(t *testing.T) {
+	l := &PropertiesLoader{
+		Reader: strings.NewReader(`
+access.key = 123456
+Normal     = normal
+db.name    = configdb
+availability.ratio = 8.23
+`),
+		CamelCase: true,
+	}
+
+	s := &CamelCaseServer{}
+	if err := l.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	testCamelcaseStruct(t, s, getDefaultCamelCaseServer())
+}
+
+func TestParseProperties(t *testing.T) {
+	src := `# comment
+! comment
+plain=value
+colon: value
+space value
+  indented = value
+empty
+continued = first, \
+            second
+escaped\ key = tab\there
+unicode = caf\u00e9 \ud83d\ude00
+backslash = C:\\temp\\
+trailing = value\\
+`
+
+	want := map[string]string{
+		"plain":       "value",
+		"colon":       "value",
+		"space":       "value",
+		"indented":    "value",
+		"empty":       "",
+		"continued":   "first, second",
+		"escaped key": "tab\there",
+		"unicode":     "café 😀",
+		"backslash":   `C:\temp\`,
+		"trailing":    `value\`,
+	}
+
+	values, err := parseProperties("", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(values) != len(want) {
+		t.Errorf("values are wrong: %d, want: %d", len(values), len(want))
+	}
+
+	for key, val := range want {
+		v, ok := values[key]
+		if !ok {
+			t.Errorf("%s is missing", key)
+			continue
+		}
+
+		if v.value != val {
+			t.Errorf("%s is wrong: %q, want: %q", key, v.value, val)
+		}
+	}
+}
+
+func TestPropertiesErrors(t *testing.T) {
+	l := &PropertiesLoader{Reader: strings.NewReader("name = koding\nkey = \\u12\n")}
+
+	var perr *ParseError
+	if err := l.Load(&Server{}); !errors.As(err, &perr) || perr.Line != 2 {
+		t.Errorf("error should be a *ParseError at line 2, got: %v", err)
+	}
+
+	l = &PropertiesLoader{
+		Reader:              strings.NewReader("postgres.port = 5432\npostgres.foo = bar\n"),
+		DisallowUnknownKeys: true,
+	}
+
+	var kerr *UnknownKeyError
+	if err := l.Load(&Server{}); !errors.As(err, &kerr) || kerr.Key != "postgres.foo" {
+		t.Errorf("error should be an *UnknownKeyError for postgres.foo, got: %v", err)
+	}
+}
@@ -0,0 +1,322 @@
+package multiconfig
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrReferenceCycle states that a field references itself, directly or
+// through other fields.
+var ErrReferenceCycle = errors.New("reference cycle")
+
+// ReferenceLoader satisifies the loader interface. It replaces the references
+// to other fields in the string values of the config struct with the values
+// of the fields, as loaded by the previous loaders:
+//
+//	data_dir = "/var/lib/myapp"
+//	log_dir = "${DataDir}/logs"
+//	public_url = "http://${Host}:${Port}"
+//
+// Fields of nested structs are referenced by their path, i.e:
+// ${Postgres.Port}, and $${Path} is a literal ${Path}. Fields are resolved
+// in the order of their references. A field that references itself, directly
+// or through other fields, returns a *FieldError wrapping ErrReferenceCycle.
+// It's placed last in MultiLoader, so the references use the values of all
+// the loaders, i.e: a flag overriding DataDir changes LogDir too:
+//
+//	MultiLoader(&TagLoader{}, &TOMLLoader{Path: path}, &EnvironmentLoader{}, &FlagLoader{}, &ReferenceLoader{})
+//
+// See InterpolationLoader.KeepReferences to combine it with
+// InterpolationLoader.
+type ReferenceLoader struct{}
+
+// Load resolves the references in the config defined by struct s.
+func (r *ReferenceLoader) Load(s interface{}) error {
+	return resolveReferences(s)
+}
+
+// SkipMerge implements the MergeSkipper interface. It returns true, as it
+// transforms the values loaded by the previous loaders.
+func (r *ReferenceLoader) SkipMerge() bool {
+	return true
+}
+
+// resolveReferences replaces the references to other fields in the string
+// values of the struct pointed by s, i.e: "${DataDir}/logs" or
+// "${Postgres.Port}". Fields are resolved in the order of their references,
+// $${Path} is replaced by a literal ${Path}. References to unknown fields are
+// left as is.
+func resolveReferences(s interface{}) error {
+	v := reflect.ValueOf(s)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+
+	r := &referenceResolver{
+		root:     v.Elem(),
+		original: make(map[string]string),
+		state:    make(map[string]int),
+	}
+
+	if err := r.resolveFields(r.root, ""); err != nil {
+		return err
+	}
+
+	// strings of slices and maps can't be referenced, resolve them last
+	return r.resolveContainers(r.root, "")
+}
+
+const (
+	unresolved = iota
+	resolving
+	resolved
+)
+
+type referenceResolver struct {
+	root reflect.Value
+
+	// original are the values of the resolved fields before resolving them
+	original map[string]string
+
+	state map[string]int
+	stack []string
+}
+
+// resolveFields resolves the string fields of struct v and its nested
+// structs.
+func (r *referenceResolver) resolveFields(v reflect.Value, path string) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		name := sf.Name
+		if path != "" {
+			name = path + "." + sf.Name
+		}
+
+		f := v.Field(i)
+		for f.Kind() == reflect.Ptr && !f.IsNil() {
+			f = f.Elem()
+		}
+
+		switch f.Kind() {
+		case reflect.Struct:
+			if err := r.resolveFields(f, name); err != nil {
+				return err
+			}
+		case reflect.String:
+			if err := r.resolveField(name, f); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveField resolves the string field f with the given path, after the
+// fields it references.
+func (r *referenceResolver) resolveField(path string, f reflect.Value) error {
+	switch r.state[path] {
+	case resolved:
+		return nil
+	case resolving:
+		chain := append(r.stack[indexOf(r.stack, path):], path)
+		return &FieldError{
+			Field: path,
+			Value: r.original[path],
+			Err:   fmt.Errorf("%w: %s", ErrReferenceCycle, strings.Join(chain, " -> ")),
+		}
+	}
+
+	r.state[path] = resolving
+	r.stack = append(r.stack, path)
+	r.original[path] = f.String()
+
+	value, err := r.expand(f.String())
+	if err != nil {
+		var ferr *FieldError
+		if errors.As(err, &ferr) {
+			return err
+		}
+		return &FieldError{Field: path, Value: r.original[path], Err: err}
+	}
+
+	f.SetString(value)
+	r.stack = r.stack[:len(r.stack)-1]
+	r.state[path] = resolved
+	return nil
+}
+
+// resolveContainers resolves the strings in the slices, maps and interfaces
+// of struct v and its nested structs.
+func (r *referenceResolver) resolveContainers(v reflect.Value, path string) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		name := sf.Name
+		if path != "" {
+			name = path + "." + sf.Name
+		}
+
+		f := v.Field(i)
+		for f.Kind() == reflect.Ptr && !f.IsNil() {
+			f = f.Elem()
+		}
+
+		switch f.Kind() {
+		case reflect.Struct:
+			if err := r.resolveContainers(f, name); err != nil {
+				return err
+			}
+		case reflect.Slice, reflect.Array, reflect.Map, reflect.Interface:
+			if err := interpolate(f, name, r.expand); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// expand replaces the field references of s with the values of the fields.
+func (r *referenceResolver) expand(s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		escaped := strings.HasPrefix(s[i:], "$${")
+		if !escaped && !strings.HasPrefix(s[i:], "${") {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		start := i + 2
+		if escaped {
+			start++
+		}
+
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			b.WriteString(s[i:])
+			break
+		}
+		end += start
+
+		path := s[start:end]
+		f, ok := r.field(path)
+		if !ok {
+			// not a reference, i.e: ${VAR:-default}
+			b.WriteByte(s[i])
+			continue
+		}
+
+		if escaped {
+			b.WriteString(s[i+1 : end+1])
+			i = end
+			continue
+		}
+
+		if f.Kind() == reflect.String {
+			if err := r.resolveField(path, f); err != nil {
+				return "", err
+			}
+		}
+
+		b.WriteString(formatValue(f))
+		i = end
+	}
+
+	return b.String(), nil
+}
+
+// field returns the field with the given path, i.e: "Postgres.Port".
+func (r *referenceResolver) field(path string) (reflect.Value, bool) {
+	return lookupPath(r.root, path)
+}
+
+// lookupPath returns the field of struct v with the given path. The names are
+// case-sensitive and the fields of nested structs are separated by dots.
+func lookupPath(v reflect.Value, path string) (reflect.Value, bool) {
+	if path == "" {
+		return reflect.Value{}, false
+	}
+
+	for _, name := range strings.Split(path, ".") {
+		for v.Kind() == reflect.Ptr && !v.IsNil() {
+			v = v.Elem()
+		}
+
+		if v.Kind() != reflect.Struct || !isFieldName(name) {
+			return reflect.Value{}, false
+		}
+
+		sf, ok := v.Type().FieldByName(name)
+		if !ok || sf.PkgPath != "" {
+			return reflect.Value{}, false
+		}
+
+		v = v.FieldByIndex(sf.Index)
+	}
+
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	return v, true
+}
+
+// isFieldName reports whether name is a valid exported field name.
+func isFieldName(name string) bool {
+	if name == "" || name[0] < 'A' || name[0] > 'Z' {
+		return false
+	}
+
+	for _, c := range name {
+		if !isVarChar(c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// formatValue returns the text of a referenced field.
+func formatValue(v reflect.Value) string {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return ""
+	}
+
+	if v.CanAddr() {
+		if fv, ok := v.Addr().Interface().(flag.Value); ok {
+			return fv.String()
+		}
+	}
+
+	return fmt.Sprint(v.Interface())
+}
+
+func indexOf(s []string, v string) int {
+	for i, e := range s {
+		if e == v {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,137 @@
+package multiconfig
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type ReferenceConfig struct {
+	DataDir   string `default:"/var/lib/myapp"`
+	LogDir    string `default:"${DataDir}/logs"`
+	Host      string `default:"localhost"`
+	Port      int    `default:"8080"`
+	PublicURL string `default:"http://${Host}:${Port}"`
+	Template  string
+	Hosts     []string
+	Postgres  struct {
+		Host string
+		Port int `default:"5432"`
+		DSN  string
+	}
+}
+
+func TestReferences(t *testing.T) {
+	json := `{
+	"datadir": "/data",
+	"template": "$${Host}",
+	"hosts": ["${Host}", "${Postgres.Host}"],
+	"postgres": {
+		"dsn": "postgres://${Postgres.Host}:${Postgres.Port}",
+		"host": "${Host}"
+	}
+}`
+
+	m := MultiLoader(
+		&TagLoader{},
+		&JSONLoader{Reader: strings.NewReader(json)},
+		&FlagLoader{Args: []string{"-host", "example.com"}},
+		&ReferenceLoader{},
+	)
+
+	s := &ReferenceConfig{}
+	if err := m.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	want := &ReferenceConfig{
+		DataDir:   "/data",
+		LogDir:    "/data/logs",
+		Host:      "example.com",
+		Port:      8080,
+		PublicURL: "http://example.com:8080",
+		Template:  "${Host}",
+		Hosts:     []string{"example.com", "example.com"},
+	}
+	want.Postgres.Host = "example.com"
+	want.Postgres.Port = 5432
+	want.Postgres.DSN = "postgres://example.com:5432"
+
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("config is wrong: %+v, want: %+v", s, want)
+	}
+}
+
+func TestReferencesInterpolation(t *testing.T) {
+	t.Setenv("REFERENCE_HOME", "/home/gopher")
+
+	json := `{"datadir": "${REFERENCE_HOME}/.myapp", "template": "$${DataDir} $$HOME"}`
+
+	m := MultiLoader(
+		&TagLoader{},
+		&JSONLoader{Reader: strings.NewReader(json)},
+		&InterpolationLoader{KeepReferences: true},
+		&ReferenceLoader{},
+	)
+
+	s := &ReferenceConfig{}
+	if err := m.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.LogDir != "/home/gopher/.myapp/logs" {
+		t.Errorf("LogDir value is wrong: %s, want: %s", s.LogDir, "/home/gopher/.myapp/logs")
+	}
+
+	if s.Template != "${DataDir} $HOME" {
+		t.Errorf("Template value is wrong: %s, want: %s", s.Template, "${DataDir} $HOME")
+	}
+}
+
+func TestReferencesDisabled(t *testing.T) {
+	json := `{"template": "literal ${Host} and $${Host} $$x"}`
+
+	m := MultiLoader(
+		&TagLoader{},
+		&JSONLoader{Reader: strings.NewReader(json)},
+	)
+
+	s := &ReferenceConfig{}
+	if err := m.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.LogDir != "${DataDir}/logs" {
+		t.Errorf("LogDir value is wrong: %s, want: %s", s.LogDir, "${DataDir}/logs")
+	}
+
+	if want := "literal ${Host} and $${Host} $$x"; s.Template != want {
+		t.Errorf("Template value is wrong: %s, want: %s", s.Template, want)
+	}
+}
+
+func TestReferencesCycle(t *testing.T) {
+	s := &ReferenceConfig{
+		DataDir: "${LogDir}",
+		LogDir:  "${DataDir}/logs",
+	}
+
+	err := (&ReferenceLoader{}).Load(s)
+	if !errors.Is(err, ErrReferenceCycle) {
+		t.Fatalf("error should be ErrReferenceCycle, got: %v", err)
+	}
+
+	var ferr *FieldError
+	if !errors.As(err, &ferr) {
+		t.Fatalf("error should be a *FieldError, got: %v", err)
+	}
+
+	if ferr.Field != "DataDir" {
+		t.Errorf("Field is wrong: %s, want: %s", ferr.Field, "DataDir")
+	}
+
+	if !strings.Contains(err.Error(), "DataDir -> LogDir -> DataDir") {
+		t.Errorf("error should contain the cycle, got: %s", err)
+	}
+}
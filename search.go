@@ -0,0 +1,76 @@
+package multiconfig
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	searchPathsMu sync.RWMutex
+
+	// searchPaths are the directories relative config paths are searched in
+	searchPaths = []string{"."}
+)
+
+// DefaultSearchPaths returns the standard directories to search the config
+// files of the given app in, in order: the working directory,
+// $XDG_CONFIG_HOME/<app>, ~/.config/<app>, /etc/<app> and the directory of
+// the executable. Directories that can't be determined are omitted.
+func DefaultSearchPaths(app string) []string {
+	paths := []string{"."}
+
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		paths = append(paths, filepath.Join(dir, app))
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".config", app))
+	}
+
+	paths = append(paths, filepath.Join("/etc", app))
+
+	if exe, err := os.Executable(); err == nil {
+		paths = append(paths, filepath.Dir(exe))
+	}
+
+	// $XDG_CONFIG_HOME is usually ~/.config
+	uniq := paths[:0]
+	for i, path := range paths {
+		if i == 0 || filepath.Clean(path) != filepath.Clean(uniq[len(uniq)-1]) {
+			uniq = append(uniq, path)
+		}
+	}
+
+	return uniq
+}
+
+// SetSearchPaths sets the directories that the file loaders search relative
+// config paths in, in order, i.e:
+//
+//	multiconfig.SetSearchPaths(multiconfig.DefaultSearchPaths("myapp")...)
+//
+// Relative directories are relative to the working directory. By default
+// only the working directory is searched.
+func SetSearchPaths(paths ...string) {
+	searchPathsMu.Lock()
+	defer searchPathsMu.Unlock()
+
+	searchPaths = append([]string(nil), paths...)
+}
+
+// SearchPaths returns the directories that the file loaders search relative
+// config paths in.
+func SearchPaths() []string {
+	searchPathsMu.RLock()
+	defer searchPathsMu.RUnlock()
+
+	return append([]string(nil), searchPaths...)
+}
+
+// FindConfig returns the path of the config file with the given name in the
+// first search path that contains it. Absolute names are returned as is if
+// the file exists. It returns ErrFileNotFound if the file isn't found.
+func FindConfig(name string) (string, error) {
+	return fileSystem{}.find(name)
+}
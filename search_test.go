@@ -0,0 +1,109 @@
+package multiconfig
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func setSearchPaths(t *testing.T, paths ...string) {
+	prev := SearchPaths()
+	t.Cleanup(func() { SetSearchPaths(prev...) })
+
+	SetSearchPaths(paths...)
+}
+
+func TestFindConfig(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+
+	for _, path := range []string{
+		filepath.Join(first, "a.toml"),
+		filepath.Join(second, "a.toml"),
+		filepath.Join(second, "b.toml"),
+	} {
+		if err := ioutil.WriteFile(path, []byte(`name = "koding"`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	setSearchPaths(t, first, second)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"a.toml", filepath.Join(first, "a.toml")},
+		{"b.toml", filepath.Join(second, "b.toml")},
+		{filepath.Join(second, "a.toml"), filepath.Join(second, "a.toml")},
+	}
+
+	for _, test := range tests {
+		path, err := FindConfig(test.name)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		if path != test.want {
+			t.Errorf("%s: path is wrong: %s, want: %s", test.name, path, test.want)
+		}
+	}
+
+	if _, err := FindConfig("c.toml"); err != ErrFileNotFound {
+		t.Errorf("error should be ErrFileNotFound, got: %v", err)
+	}
+
+	s := &Server{}
+	if err := (&TOMLLoader{Path: "b.toml"}).Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Name != "koding" {
+		t.Errorf("Name value is wrong: %s, want: %s", s.Name, "koding")
+	}
+}
+
+func TestFileLoaderSearchPathsError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "broken.json")
+	if err := ioutil.WriteFile(path, []byte(`{"name": `), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	setSearchPaths(t, dir)
+
+	err := (&FileLoader{Path: "broken.json"}).Load(&Server{})
+
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("error should be a *ParseError, got: %v", err)
+	}
+
+	if perr.Path != path {
+		t.Errorf("Path is wrong: %s, want: %s", perr.Path, path)
+	}
+}
+
+func TestDefaultSearchPaths(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+
+	paths := DefaultSearchPaths("myapp")
+
+	want := []string{".", "/tmp/xdg/myapp"}
+	if home, err := os.UserHomeDir(); err == nil {
+		want = append(want, filepath.Join(home, ".config", "myapp"))
+	}
+	want = append(want, "/etc/myapp")
+
+	if len(paths) < len(want) {
+		t.Fatalf("paths are wrong: %v, want prefix: %v", paths, want)
+	}
+
+	for i, path := range want {
+		if paths[i] != path {
+			t.Errorf("path %d is wrong: %s, want: %s", i, paths[i], path)
+		}
+	}
+}
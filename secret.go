@@ -0,0 +1,239 @@
+package multiconfig
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// SecretResolver resolves the references to secrets of a URI scheme, i.e:
+// "file:///run/secrets/db" for the "file" scheme. Resolvers are registered
+// with RegisterSecretResolver or given to SecretLoader.
+type SecretResolver interface {
+	// Resolve returns the secret referenced by ref, including its scheme.
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// SecretResolverFunc is an adapter to use an ordinary function as a
+// SecretResolver.
+type SecretResolverFunc func(ctx context.Context, ref string) (string, error)
+
+// Resolve calls f(ctx, ref).
+func (f SecretResolverFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+var (
+	secretResolversMu sync.RWMutex
+
+	secretResolvers = map[string]SecretResolver{
+		"file": SecretResolverFunc(resolveFile),
+		"env":  SecretResolverFunc(resolveEnv),
+	}
+)
+
+// RegisterSecretResolver registers the resolver of the given URI scheme, i.e:
+// "vault". A resolver with the same scheme, including the built-in file and
+// env resolvers, is replaced. It panics if the scheme is empty or the
+// resolver is nil.
+func RegisterSecretResolver(scheme string, r SecretResolver) {
+	if scheme == "" || r == nil {
+		panic("multiconfig: secret resolver must have a scheme and a resolver")
+	}
+
+	secretResolversMu.Lock()
+	defer secretResolversMu.Unlock()
+
+	secretResolvers[strings.ToLower(scheme)] = r
+}
+
+// SecretLoader satisifies the loader interface. It replaces the references to
+// secrets in the string values of the config struct, as loaded by the
+// previous loaders, with the secrets:
+//
+//	password = "file:///run/secrets/db"
+//	token = "env://API_TOKEN"
+//
+// A value is a reference if it starts with the scheme of a resolver followed
+// by "://". Values with other schemes, i.e: "http://localhost", are left as
+// is. The file resolver reads the file without its trailing newlines and the
+// env resolver reads the environment variable, returning an error if it's
+// unset. Errors are returned as *FieldError.
+// It's placed in MultiLoader after the loaders whose values should be
+// resolved:
+//
+//	MultiLoader(&TagLoader{}, &TOMLLoader{Path: path}, &EnvironmentLoader{}, &SecretLoader{})
+type SecretLoader struct {
+	// Resolvers are the resolvers by URI scheme, i.e: "vault". They take
+	// precedence over the registered resolvers.
+	Resolvers map[string]SecretResolver
+
+	// TaggedOnly resolves only the fields tagged with `secret:"true"`. The
+	// strings of their slices and maps are resolved too.
+	TaggedOnly bool
+
+	// Exec enables the exec resolver, which runs the command of references
+	// like "exec://pass show db", split by spaces, and reads its output
+	// without the trailing newlines. It's disabled by default, as the
+	// commands are run by anyone able to change the config.
+	Exec bool
+}
+
+// Load resolves the secrets in the config defined by struct s.
+func (l *SecretLoader) Load(s interface{}) error {
+	return l.LoadContext(context.Background(), s)
+}
+
+// SkipMerge implements the MergeSkipper interface. It returns true, as it
+// transforms the values loaded by the previous loaders.
+func (l *SecretLoader) SkipMerge() bool {
+	return true
+}
+
+// LoadContext resolves the secrets in the config defined by struct s. The
+// context is passed to the resolvers.
+func (l *SecretLoader) LoadContext(ctx context.Context, s interface{}) error {
+	expand := func(v string) (string, error) {
+		r, ok := l.resolver(v)
+		if !ok {
+			return v, nil
+		}
+
+		return r.Resolve(ctx, v)
+	}
+
+	v := reflect.ValueOf(s).Elem()
+	if !l.TaggedOnly {
+		return interpolate(v, "", expand)
+	}
+
+	return resolveTagged(v, "", expand)
+}
+
+// resolver returns the resolver of the scheme of ref.
+func (l *SecretLoader) resolver(ref string) (SecretResolver, bool) {
+	i := strings.Index(ref, "://")
+	if i <= 0 {
+		return nil, false
+	}
+
+	scheme := strings.ToLower(ref[:i])
+
+	if r, ok := l.Resolvers[scheme]; ok {
+		return r, true
+	}
+
+	if l.Exec && scheme == "exec" {
+		return SecretResolverFunc(resolveExec), true
+	}
+
+	secretResolversMu.RLock()
+	defer secretResolversMu.RUnlock()
+
+	r, ok := secretResolvers[scheme]
+	return r, ok
+}
+
+// resolveTagged calls interpolate for the fields of struct v, and its nested
+// structs, tagged with `secret:"true"`.
+func resolveTagged(v reflect.Value, path string, expand func(string) (string, error)) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		name := sf.Name
+		if path != "" {
+			name = path + "." + sf.Name
+		}
+
+		f := v.Field(i)
+		if sf.Tag.Get("secret") == "true" {
+			if err := interpolate(f, name, expand); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for f.Kind() == reflect.Ptr && !f.IsNil() {
+			f = f.Elem()
+		}
+
+		if f.Kind() == reflect.Struct {
+			if err := resolveTagged(f, name, expand); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// refTarget returns the part of ref after the scheme, i.e: "/run/secrets/db"
+// for "file:///run/secrets/db".
+func refTarget(ref string) string {
+	if i := strings.Index(ref, "://"); i >= 0 {
+		return ref[i+3:]
+	}
+
+	return ref
+}
+
+// resolveFile reads the file of a "file://" reference.
+func resolveFile(ctx context.Context, ref string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	data, err := ioutil.ReadFile(refTarget(ref))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// resolveEnv reads the environment variable of an "env://" reference.
+func resolveEnv(ctx context.Context, ref string) (string, error) {
+	name := refTarget(ref)
+
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("%s is not set", name)
+	}
+
+	return v, nil
+}
+
+// resolveExec runs the command of an "exec://" reference.
+func resolveExec(ctx context.Context, ref string) (string, error) {
+	args := strings.Fields(refTarget(ref))
+	if len(args) == 0 {
+		return "", errors.New("command is empty")
+	}
+
+	var stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("%s: %w", args[0], err)
+	}
+
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
@@ -0,0 +1,155 @@
+package multiconfig
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type SecretConfig struct {
+	Password string `secret:"true"`
+	Token    string `secret:"true"`
+	Key      string
+	URL      string
+	Tokens   map[string]string
+	Postgres struct {
+		Password string `secret:"true"`
+		User     string
+	}
+}
+
+func TestSecretLoader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db")
+	if err := ioutil.WriteFile(path, []byte("hunter2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("SECRET_TOKEN", "s3cr3t")
+
+	s := &SecretConfig{
+		Password: "file://" + path,
+		Token:    "env://SECRET_TOKEN",
+		Key:      "exec://echo  -n key",
+		URL:      "http://localhost",
+		Tokens:   map[string]string{"api": "vault://secret/api"},
+	}
+	s.Postgres.Password = "ENV://SECRET_TOKEN"
+	s.Postgres.User = "env://SECRET_TOKEN"
+
+	vault := SecretResolverFunc(func(ctx context.Context, ref string) (string, error) {
+		return strings.TrimPrefix(ref, "vault://") + "-token", nil
+	})
+
+	l := &SecretLoader{Resolvers: map[string]SecretResolver{"vault": vault}, Exec: true}
+	if err := l.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	want := &SecretConfig{
+		Password: "hunter2",
+		Token:    "s3cr3t",
+		Key:      "key",
+		URL:      "http://localhost",
+		Tokens:   map[string]string{"api": "secret/api-token"},
+	}
+	want.Postgres.Password = "s3cr3t"
+	want.Postgres.User = "s3cr3t"
+
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("config is wrong: %+v, want: %+v", s, want)
+	}
+}
+
+func TestSecretLoaderTaggedOnly(t *testing.T) {
+	t.Setenv("SECRET_TOKEN", "s3cr3t")
+
+	json := `{
+	"token": "env://SECRET_TOKEN",
+	"key": "env://SECRET_TOKEN",
+	"postgres": {"password": "env://SECRET_TOKEN", "user": "env://SECRET_TOKEN"}
+}`
+
+	m := MultiLoader(
+		&JSONLoader{Reader: strings.NewReader(json)},
+		&SecretLoader{TaggedOnly: true},
+	)
+
+	s := &SecretConfig{}
+	if err := m.Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Token != "s3cr3t" {
+		t.Errorf("Token value is wrong: %s, want: %s", s.Token, "s3cr3t")
+	}
+
+	if s.Postgres.Password != "s3cr3t" {
+		t.Errorf("Postgres.Password value is wrong: %s, want: %s", s.Postgres.Password, "s3cr3t")
+	}
+
+	if s.Key != "env://SECRET_TOKEN" {
+		t.Errorf("Key value is wrong: %s, want: %s", s.Key, "env://SECRET_TOKEN")
+	}
+
+	if s.Postgres.User != "env://SECRET_TOKEN" {
+		t.Errorf("Postgres.User value is wrong: %s, want: %s", s.Postgres.User, "env://SECRET_TOKEN")
+	}
+}
+
+func TestSecretLoaderError(t *testing.T) {
+	s := &SecretConfig{}
+	s.Postgres.Password = "env://SECRET_MISSING"
+
+	var ferr *FieldError
+	if err := (&SecretLoader{}).Load(s); !errors.As(err, &ferr) {
+		t.Fatalf("error should be a *FieldError, got: %v", err)
+	}
+
+	if ferr.Field != "Postgres.Password" {
+		t.Errorf("Field is wrong: %s, want: %s", ferr.Field, "Postgres.Password")
+	}
+
+	if ferr.Err.Error() != "SECRET_MISSING is not set" {
+		t.Errorf("Err is wrong: %s", ferr.Err)
+	}
+
+	s = &SecretConfig{Key: "exec://false"}
+	if err := (&SecretLoader{Exec: true}).Load(s); err == nil {
+		t.Error("error should be returned for a failing command")
+	}
+}
+
+func TestSecretLoaderExecDisabled(t *testing.T) {
+	s := &SecretConfig{Key: "exec://echo key"}
+	if err := (&SecretLoader{}).Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Key != "exec://echo key" {
+		t.Errorf("Key value is wrong: %s, want: %s", s.Key, "exec://echo key")
+	}
+}
+
+func TestRegisterSecretResolver(t *testing.T) {
+	RegisterSecretResolver("test", SecretResolverFunc(func(ctx context.Context, ref string) (string, error) {
+		return "registered", nil
+	}))
+	defer func() {
+		secretResolversMu.Lock()
+		delete(secretResolvers, "test")
+		secretResolversMu.Unlock()
+	}()
+
+	s := &SecretConfig{Key: "test://key"}
+	if err := (&SecretLoader{}).Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Key != "registered" {
+		t.Errorf("Key value is wrong: %s, want: %s", s.Key, "registered")
+	}
+}
@@ -0,0 +1,537 @@
+package multiconfig
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var (
+	// ErrSOPSKeyNotFound states that none of the keys of a SOPS encrypted
+	// file is available to decrypt its data key.
+	ErrSOPSKeyNotFound = errors.New("sops data key can't be decrypted")
+
+	// ErrSOPSMACMismatch states that the MAC of a SOPS encrypted file doesn't
+	// match its content, i.e: the file was modified after encryption.
+	ErrSOPSMACMismatch = errors.New("sops MAC mismatch")
+)
+
+var (
+	// sopsValue matches a value encrypted by SOPS.
+	sopsValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+	// sopsMACOnlyEncrypted are the first bytes of the MAC of the files whose
+	// MAC only includes the encrypted values.
+	sopsMACOnlyEncrypted = []byte{0x8a, 0x3f, 0xd2, 0xad, 0x54, 0xce, 0x66, 0x52, 0x7b, 0x10, 0x34, 0xf3, 0xd1, 0x47, 0xbe, 0xb, 0xb, 0x97, 0x5b, 0x3b, 0xf4, 0x4f, 0x72, 0xc6, 0xfd, 0xad, 0xec, 0x81, 0x76, 0xf2, 0x7d, 0x69}
+)
+
+// sopsComment is a decrypted comment, which isn't part of the decrypted
+// file.
+type sopsComment string
+
+// SOPSKeys is the key material to decrypt the files encrypted with SOPS. The
+// yaml and json files of FileLoader, JSONLoader and YAMLLoader are detected
+// by their top level "sops" key, with a "mac" and an "age" or "lastmodified"
+// key, and decrypted before decoding. The MAC of the file is verified.
+//
+// The data key of the file is decrypted with the age identities of AgeKey
+// and AgeKeyFile. If none are set, they're read as SOPS does, from the
+// SOPS_AGE_KEY and SOPS_AGE_KEY_FILE environment variables, or from the
+// sops/age/keys.txt file of the user config directory. Other key services,
+// like KMS or PGP, aren't supported, but the data key can be given directly
+// with DataKey.
+type SOPSKeys struct {
+	// AgeKey are age identities, one per line, as generated by age-keygen.
+	AgeKey string
+
+	// AgeKeyFile is the path of a file with age identities.
+	AgeKeyFile string
+
+	// DataKey is the data key of the file, used instead of the age
+	// identities.
+	DataKey []byte
+}
+
+// sopsMetadata is the part of the sops metadata used to decrypt a file.
+type sopsMetadata struct {
+	Age []struct {
+		Recipient string `yaml:"recipient" json:"recipient"`
+		Enc       string `yaml:"enc" json:"enc"`
+	} `yaml:"age" json:"age"`
+	LastModified     string `yaml:"lastmodified" json:"lastmodified"`
+	MAC              string `yaml:"mac" json:"mac"`
+	MACOnlyEncrypted bool   `yaml:"mac_only_encrypted" json:"mac_only_encrypted"`
+}
+
+// decryptSOPS returns the decrypted content of data in the given format,
+// "yaml" or "json", if it's encrypted with SOPS, otherwise data as is.
+func (k *SOPSKeys) decryptSOPS(data []byte, format string) ([]byte, error) {
+	if !bytes.Contains(data, []byte("sops")) {
+		return data, nil
+	}
+
+	var (
+		tree yaml.MapSlice
+		err  error
+	)
+
+	switch format {
+	case "yaml":
+		err = yaml.Unmarshal(data, &tree)
+	case "json":
+		tree, err = decodeOrderedJSON(data)
+	default:
+		return data, nil
+	}
+
+	if err != nil {
+		// leave the syntax errors to the loader
+		return data, nil
+	}
+
+	i := indexOfKey(tree, "sops")
+	if i < 0 {
+		return data, nil
+	}
+
+	meta, ok, err := decodeSOPSMetadata(tree[i].Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		// a "sops" key of the config itself
+		return data, nil
+	}
+	tree = append(tree[:i:i], tree[i+1:]...)
+
+	key, err := k.dataKey(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &sopsDecrypter{key: key, macOnlyEncrypted: meta.MACOnlyEncrypted, hash: sha512.New()}
+	if meta.MACOnlyEncrypted {
+		d.hash.Write(sopsMACOnlyEncrypted)
+	}
+
+	v, err := d.decrypt(tree, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.verify(meta); err != nil {
+		return nil, err
+	}
+
+	if format == "json" {
+		var b bytes.Buffer
+		if err := encodeOrderedJSON(&b, v); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+
+	return yaml.Marshal(v)
+}
+
+// dataKey returns the data key of the file with the given metadata.
+func (k *SOPSKeys) dataKey(meta *sopsMetadata) ([]byte, error) {
+	if k != nil && k.DataKey != nil {
+		return k.DataKey, nil
+	}
+
+	if len(meta.Age) == 0 {
+		return nil, fmt.Errorf("%w: no age recipients in the sops metadata", ErrSOPSKeyNotFound)
+	}
+
+	identities, err := k.ageIdentities()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(identities) == 0 {
+		return nil, fmt.Errorf("%w: no age identities, set SOPS_AGE_KEY or SOPS_AGE_KEY_FILE", ErrSOPSKeyNotFound)
+	}
+
+	for _, recipient := range meta.Age {
+		r, err := age.Decrypt(armor.NewReader(strings.NewReader(recipient.Enc)), identities...)
+		if err != nil {
+			continue
+		}
+
+		return ioutil.ReadAll(r)
+	}
+
+	recipients := make([]string, len(meta.Age))
+	for i, recipient := range meta.Age {
+		recipients[i] = recipient.Recipient
+	}
+
+	return nil, fmt.Errorf("%w: no age identity for the recipients %s", ErrSOPSKeyNotFound, strings.Join(recipients, ", "))
+}
+
+// ageIdentities returns the age identities of the keys, or the ones of the
+// SOPS environment if none are set.
+func (k *SOPSKeys) ageIdentities() ([]age.Identity, error) {
+	var keys, files []string
+
+	if k != nil && (k.AgeKey != "" || k.AgeKeyFile != "") {
+		keys, files = []string{k.AgeKey}, []string{k.AgeKeyFile}
+	} else {
+		keys = []string{os.Getenv("SOPS_AGE_KEY")}
+		files = []string{os.Getenv("SOPS_AGE_KEY_FILE")}
+		if dir, err := os.UserConfigDir(); err == nil {
+			files = append(files, filepath.Join(dir, "sops", "age", "keys.txt"))
+		}
+	}
+
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(file)
+		if os.IsNotExist(err) && (k == nil || k.AgeKeyFile == "") {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, string(data))
+	}
+
+	var identities []age.Identity
+	for _, key := range keys {
+		if strings.TrimSpace(key) == "" {
+			continue
+		}
+
+		ids, err := age.ParseIdentities(strings.NewReader(key))
+		if err != nil {
+			return nil, fmt.Errorf("multiconfig: invalid age identities: %w", err)
+		}
+
+		identities = append(identities, ids...)
+	}
+
+	return identities, nil
+}
+
+// decodeSOPSMetadata decodes the value of the "sops" key. It returns false if
+// the value isn't sops metadata, which has a "mac" key and an "age" or
+// "lastmodified" key.
+func decodeSOPSMetadata(v interface{}) (*sopsMetadata, bool, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var keys map[string]interface{}
+	if err := yaml.Unmarshal(data, &keys); err != nil {
+		return nil, false, nil
+	}
+
+	_, hasMAC := keys["mac"]
+	_, hasAge := keys["age"]
+	_, hasLastModified := keys["lastmodified"]
+	if !hasMAC || !hasAge && !hasLastModified {
+		return nil, false, nil
+	}
+
+	meta := &sopsMetadata{}
+	if err := yaml.Unmarshal(data, meta); err != nil {
+		return nil, false, fmt.Errorf("multiconfig: invalid sops metadata: %w", err)
+	}
+
+	if meta.MAC == "" {
+		return nil, false, errors.New("multiconfig: invalid sops metadata: no MAC")
+	}
+
+	return meta, true, nil
+}
+
+// sopsDecrypter decrypts the values of a SOPS encrypted tree, and computes
+// its MAC.
+type sopsDecrypter struct {
+	key              []byte
+	macOnlyEncrypted bool
+	hash             hash.Hash
+}
+
+// decrypt returns v with its values decrypted. path are the keys of v.
+func (d *sopsDecrypter) decrypt(v interface{}, path []string) (interface{}, error) {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		out := make(yaml.MapSlice, 0, len(v))
+		for _, item := range v {
+			value, err := d.decrypt(item.Value, append(path[:len(path):len(path)], fmt.Sprint(item.Key)))
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := value.(sopsComment); !ok {
+				out = append(out, yaml.MapItem{Key: item.Key, Value: value})
+			}
+		}
+		return out, nil
+	case []interface{}:
+		// list items are encrypted with the path of the list
+		out := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			value, err := d.decrypt(item, path)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := value.(sopsComment); !ok {
+				out = append(out, value)
+			}
+		}
+		return out, nil
+	}
+
+	s, ok := v.(string)
+	encrypted := ok && sopsValue.MatchString(s)
+
+	if encrypted {
+		var err error
+		if v, err = decryptSOPSValue(s, d.key, strings.Join(path, ":")+":"); err != nil {
+			return nil, fmt.Errorf("multiconfig: sops value %s: %w", strings.Join(path, "."), err)
+		}
+	}
+
+	if _, ok := v.(sopsComment); !ok && (encrypted || !d.macOnlyEncrypted) {
+		io.WriteString(d.hash, sopsBytes(v))
+	}
+
+	return v, nil
+}
+
+// verify compares the MAC of the decrypted values with the MAC of the file.
+func (d *sopsDecrypter) verify(meta *sopsMetadata) error {
+	// the MAC is encrypted with the modification time in RFC 3339
+	lastModified := meta.LastModified
+	if t, err := time.Parse(time.RFC3339, lastModified); err == nil {
+		lastModified = t.Format(time.RFC3339)
+	}
+
+	mac, err := decryptSOPSValue(meta.MAC, d.key, lastModified)
+	if err != nil {
+		return fmt.Errorf("multiconfig: sops MAC: %w", err)
+	}
+
+	if !strings.EqualFold(fmt.Sprint(mac), fmt.Sprintf("%X", d.hash.Sum(nil))) {
+		return ErrSOPSMACMismatch
+	}
+
+	return nil
+}
+
+// decryptSOPSValue decrypts a value in the form of
+// "ENC[AES256_GCM,data:...,iv:...,tag:...,type:...]", with the given
+// additional data, and returns it with its type.
+func decryptSOPSValue(value string, key []byte, additionalData string) (interface{}, error) {
+	m := sopsValue.FindStringSubmatch(value)
+	if m == nil {
+		return nil, ErrDecrypt
+	}
+
+	var parts [3][]byte
+	for i := range parts {
+		b, err := base64.StdEncoding.DecodeString(m[i+1])
+		if err != nil {
+			return nil, ErrDecrypt
+		}
+		parts[i] = b
+	}
+	data, iv, tag := parts[0], parts[1], parts[2]
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("multiconfig: invalid sops data key: %w", err)
+	}
+
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return nil, err
+	}
+
+	plain, err := gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	switch typ := m[4]; typ {
+	case "str", "bytes", "time":
+		return string(plain), nil
+	case "comment":
+		return sopsComment(plain), nil
+	case "int":
+		return strconv.Atoi(string(plain))
+	case "float":
+		return strconv.ParseFloat(string(plain), 64)
+	case "bool":
+		return strconv.ParseBool(string(plain))
+	default:
+		return nil, fmt.Errorf("unknown sops value type %q", typ)
+	}
+}
+
+// sopsBytes returns the bytes of a value hashed in the MAC, as SOPS does.
+func sopsBytes(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "True"
+		}
+		return "False"
+	}
+
+	return fmt.Sprint(v)
+}
+
+// indexOfKey returns the index of the item with the given key, or -1.
+func indexOfKey(m yaml.MapSlice, key string) int {
+	for i, item := range m {
+		if k, ok := item.Key.(string); ok && k == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// decodeOrderedJSON decodes a json object, keeping the order of the keys.
+func decodeOrderedJSON(data []byte) (yaml.MapSlice, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	v, err := decodeJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := v.(yaml.MapSlice)
+	if !ok {
+		return nil, errors.New("json value is not an object")
+	}
+
+	return m, nil
+}
+
+func decodeJSONValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		m := yaml.MapSlice{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			m = append(m, yaml.MapItem{Key: key, Value: value})
+		}
+		_, err := dec.Token()
+		return m, err
+	case json.Delim('['):
+		l := []interface{}{}
+		for dec.More() {
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, value)
+		}
+		_, err := dec.Token()
+		return l, err
+	}
+
+	// numbers are hashed as SOPS reads them, as int or float64
+	if n, ok := tok.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return int(i), nil
+		}
+		return n.Float64()
+	}
+
+	return tok, nil
+}
+
+// encodeOrderedJSON encodes v as json, keeping the order of the keys.
+func encodeOrderedJSON(w *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		w.WriteByte('{')
+		for i, item := range v {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+
+			key, err := json.Marshal(fmt.Sprint(item.Key))
+			if err != nil {
+				return err
+			}
+			w.Write(key)
+			w.WriteByte(':')
+
+			if err := encodeOrderedJSON(w, item.Value); err != nil {
+				return err
+			}
+		}
+		w.WriteByte('}')
+	case []interface{}:
+		w.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+
+			if err := encodeOrderedJSON(w, item); err != nil {
+				return err
+			}
+		}
+		w.WriteByte(']')
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		w.Write(data)
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package multiconfig
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+)
+
+type SOPSConfig struct {
+	Name     string
+	Enabled  bool
+	Port     int
+	Ratio    float64
+	Users    []string
+	Postgres struct {
+		Enabled  bool
+		Port     int
+		Hostname string
+		Password string `json:"password_unencrypted" yaml:"password_unencrypted"`
+	}
+}
+
+func getSOPSConfig() *SOPSConfig {
+	s := &SOPSConfig{
+		Name:    "koding",
+		Enabled: true,
+		Port:    6060,
+		Ratio:   0.5,
+		Users:   []string{"ankara", "istanbul"},
+	}
+	s.Postgres.Enabled = true
+	s.Postgres.Port = 5432
+	s.Postgres.Hostname = "192.168.2.1"
+	return s
+}
+
+func TestSOPS(t *testing.T) {
+	keys := &SOPSKeys{AgeKeyFile: filepath.Join(testSOPS, "keys.txt")}
+
+	tests := []struct {
+		name   string
+		loader Loader
+		want   func(s *SOPSConfig)
+	}{
+		{
+			name:   "yaml",
+			loader: &YAMLLoader{Path: filepath.Join(testSOPS, "config.yaml"), SOPS: keys},
+			want:   func(s *SOPSConfig) { s.Postgres.Password = "plain" },
+		},
+		{
+			name:   "json",
+			loader: &JSONLoader{Path: filepath.Join(testSOPS, "config.json"), SOPS: keys},
+		},
+		{
+			name:   "file",
+			loader: &FileLoader{Path: filepath.Join(testSOPS, "config.yaml"), SOPS: keys},
+			want:   func(s *SOPSConfig) { s.Postgres.Password = "plain" },
+		},
+		{
+			name:   "mac only encrypted",
+			loader: &YAMLLoader{Path: filepath.Join(testSOPS, "mac-only-encrypted.yaml"), SOPS: keys},
+			want:   func(s *SOPSConfig) { s.Postgres.Password = "plain" },
+		},
+	}
+
+	for _, test := range tests {
+		s := &SOPSConfig{}
+		if err := test.loader.Load(s); err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		want := getSOPSConfig()
+		if test.want != nil {
+			test.want(want)
+		}
+
+		if !reflect.DeepEqual(s, want) {
+			t.Errorf("%s: config is wrong: %+v, want: %+v", test.name, s, want)
+		}
+	}
+}
+
+func TestSOPSEnvironment(t *testing.T) {
+	t.Setenv("SOPS_AGE_KEY", "")
+	t.Setenv("SOPS_AGE_KEY_FILE", filepath.Join(testSOPS, "keys.txt"))
+
+	s := &SOPSConfig{}
+	if err := (&FileLoader{Path: filepath.Join(testSOPS, "config.json")}).Load(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := getSOPSConfig(); !reflect.DeepEqual(s, want) {
+		t.Errorf("config is wrong: %+v, want: %+v", s, want)
+	}
+}
+
+func TestSOPSKeyNotFound(t *testing.T) {
+	t.Setenv("SOPS_AGE_KEY", "")
+	t.Setenv("SOPS_AGE_KEY_FILE", "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	path := filepath.Join(testSOPS, "config.yaml")
+
+	err := (&YAMLLoader{Path: path}).Load(&SOPSConfig{})
+	if !errors.Is(err, ErrSOPSKeyNotFound) {
+		t.Errorf("error should be ErrSOPSKeyNotFound, got: %v", err)
+	}
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = (&YAMLLoader{Path: path, SOPS: &SOPSKeys{AgeKey: identity.String()}}).Load(&SOPSConfig{})
+	if !errors.Is(err, ErrSOPSKeyNotFound) {
+		t.Errorf("error should be ErrSOPSKeyNotFound, got: %v", err)
+	}
+
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Path != path {
+		t.Errorf("error should be a *ParseError for %s, got: %v", path, err)
+	}
+}
+
+func TestSOPSMACMismatch(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join(testSOPS, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data = []byte(strings.Replace(string(data), "password_unencrypted: plain", "password_unencrypted: tampered", 1))
+
+	keys := &SOPSKeys{AgeKeyFile: filepath.Join(testSOPS, "keys.txt")}
+
+	err = (&YAMLLoader{Reader: strings.NewReader(string(data)), SOPS: keys}).Load(&SOPSConfig{})
+	if !errors.Is(err, ErrSOPSMACMismatch) {
+		t.Errorf("error should be ErrSOPSMACMismatch, got: %v", err)
+	}
+}
+
+func TestSOPSPlainKey(t *testing.T) {
+	type Config struct {
+		Name string
+		Sops struct {
+			Enabled string
+		}
+	}
+
+	tests := []struct {
+		name   string
+		loader Loader
+	}{
+		{
+			name:   "yaml",
+			loader: &YAMLLoader{Reader: strings.NewReader("name: koding\nsops:\n  enabled: \"true\"\n")},
+		},
+		{
+			name:   "json",
+			loader: &JSONLoader{Reader: strings.NewReader(`{"name": "koding", "sops": {"enabled": "true"}}`)},
+		},
+	}
+
+	for _, test := range tests {
+		s := &Config{}
+		if err := test.loader.Load(s); err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		if s.Name != "koding" || s.Sops.Enabled != "true" {
+			t.Errorf("%s: config is wrong: %+v", test.name, s)
+		}
+	}
+}
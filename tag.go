@@ -1,6 +1,7 @@
 package multiconfig
 
 import (
+	"os"
 	"reflect"
 
 	"github.com/fatih/structs"
@@ -17,6 +18,17 @@ type TagLoader struct {
 	//
 	// The default value is "default" if it's not set explicitly.
 	DefaultTagName string
+
+	// ExpandEnv expands the environment variables referenced in the default
+	// values before they're converted to the type of the field, in the same
+	// way InterpolationLoader does. Unlike InterpolationLoader, it works for
+	// fields of any type:
+	//
+	//   Port int `default:"${PORT:-8080}"`
+	//
+	// The references to the fields of the struct, i.e: ${DataDir}, are left
+	// to a ReferenceLoader.
+	ExpandEnv bool
 }
 
 func (t *TagLoader) Load(s interface{}) error {
@@ -24,9 +36,22 @@ func (t *TagLoader) Load(s interface{}) error {
 		t.DefaultTagName = "default"
 	}
 
+	var expand func(string) (string, error)
+	if t.ExpandEnv {
+		root := reflect.ValueOf(s).Elem()
+		isField := func(path string) bool {
+			_, ok := lookupPath(root, path)
+			return ok
+		}
+
+		expand = func(v string) (string, error) {
+			return expandEnv(v, os.LookupEnv, isField)
+		}
+	}
+
 	for _, field := range structs.Fields(s) {
 
-		if err := t.processField(t.DefaultTagName, field); err != nil {
+		if err := t.processField(t.DefaultTagName, field.Name(), field, expand); err != nil {
 			return err
 		}
 	}
@@ -35,12 +60,13 @@ func (t *TagLoader) Load(s interface{}) error {
 }
 
 // processField gets tagName and the field, recursively checks if the field has the given
-// tag, if yes, sets it otherwise ignores
-func (t *TagLoader) processField(tagName string, field *structs.Field) error {
+// tag, if yes, sets it otherwise ignores. path is the path of the field, and
+// expand, if not nil, expands the environment variables of the default value.
+func (t *TagLoader) processField(tagName, path string, field *structs.Field, expand func(string) (string, error)) error {
 	switch field.Kind() {
 	case reflect.Struct:
 		for _, f := range field.Fields() {
-			if err := t.processField(tagName, f); err != nil {
+			if err := t.processField(tagName, fieldPath(path, f.Name()), f, expand); err != nil {
 				return err
 			}
 		}
@@ -50,7 +76,15 @@ func (t *TagLoader) processField(tagName string, field *structs.Field) error {
 			return nil
 		}
 
-		err := fieldSet(field, defaultVal)
+		if expand != nil {
+			v, err := expand(defaultVal)
+			if err != nil {
+				return &FieldError{Field: path, Value: defaultVal, Err: err}
+			}
+			defaultVal = v
+		}
+
+		err := fieldSet(field, path, defaultVal)
 		if err != nil {
 			return err
 		}
@@ -1,7 +1,6 @@
 package multiconfig
 
 import (
-	"fmt"
 	"reflect"
 
 	"github.com/fatih/structs"
@@ -26,7 +25,8 @@ type RequiredValidator struct {
 
 // Validate validates the given struct agaist field's zero values. If
 // intentionaly, the value of a field is `zero-valued`(e.g false, 0, "")
-// required tag should not be set for that field.
+// required tag should not be set for that field. The returned error is a
+// *RequiredError.
 func (e *RequiredValidator) Validate(s interface{}) error {
 	if e.TagName == "" {
 		e.TagName = "required"
@@ -65,7 +65,7 @@ func (e *RequiredValidator) processField(fieldName string, field *structs.Field)
 		}
 
 		if field.IsZero() {
-			return fmt.Errorf("multiconfig: field '%s' is required", fieldName)
+			return &RequiredError{Field: fieldName}
 		}
 	}
 
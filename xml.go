@@ -0,0 +1,371 @@
+package multiconfig
+
+import (
+	"encoding/xml"
+	"errors"
+	"flag"
+	"io"
+	"io/fs"
+	"reflect"
+	"strings"
+
+	"github.com/fatih/structs"
+)
+
+// XMLLoader satisifies the loader interface. It loads the configuration from
+// the given xml file or Reader. The children elements and the attributes of
+// the root element are mapped to the fields of the struct, nested elements to
+// nested structs and repeated elements to slices:
+//
+//	<server name="koding">
+//	  <port>6060</port>
+//	  <users>ankara</users>
+//	  <users>istanbul</users>
+//	  <postgres enabled="true">
+//	    <port>5432</port>
+//	  </postgres>
+//	</server>
+//
+// Elements and attributes are matched case-insensitively with the name in the
+// field's "xml" tag, or with the field's name if there is no tag. A field
+// tagged with ",attr" is only matched with attributes and a field tagged
+// with ",chardata" is set to the text of the element. Repeated elements of a
+// slice may also be wrapped in a single element.
+type XMLLoader struct {
+	Path   string
+	Reader io.Reader
+	FS     fs.FS
+
+	// DisallowUnknownKeys causes Load to return an *UnknownKeyError if the
+	// source contains an element or attribute that doesn't match any field of
+	// the struct.
+	DisallowUnknownKeys bool
+}
+
+// xmlNode is an element of the parsed xml document.
+type xmlNode struct {
+	name     string
+	attrs    []*xmlAttr
+	children []*xmlNode
+	text     string
+	used     bool
+}
+
+type xmlAttr struct {
+	name  string
+	value string
+	used  bool
+}
+
+// Load loads the source into the config defined by struct s.
+// Defaults to using the Reader if provided, otherwise tries to read from the
+// file
+func (x *XMLLoader) Load(s interface{}) error {
+	var r io.Reader
+
+	if x.Reader != nil {
+		r = x.Reader
+	} else if x.Path != "" {
+		file, _, err := fileSystem{x.FS}.open(x.Path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	} else {
+		return ErrSourceNotSet
+	}
+
+	root, err := parseXML(r)
+	if err != nil {
+		perr := &ParseError{Path: x.Path, Err: err}
+
+		var serr *xml.SyntaxError
+		if errors.As(err, &serr) {
+			perr.Line = serr.Line
+		}
+
+		return perr
+	}
+
+	if err := x.setStruct(reflect.ValueOf(s).Elem(), "", root); err != nil {
+		return &ParseError{Path: x.Path, Err: err}
+	}
+
+	if x.DisallowUnknownKeys {
+		if key := unusedXML("", root); key != "" {
+			return &UnknownKeyError{Key: key}
+		}
+	}
+
+	return nil
+}
+
+// setStruct sets the fields of the struct v from the attributes and children
+// of node n. path is the path of the struct.
+func (x *XMLLoader) setStruct(v reflect.Value, path string, n *xmlNode) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		name, opts := sf.Name, ""
+		if tag := sf.Tag.Get("xml"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+
+			parts := strings.SplitN(tag, ",", 2)
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			if len(parts) == 2 {
+				opts = parts[1]
+			}
+		}
+
+		fv := v.Field(i)
+
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct && opts == "" {
+			if err := x.setStruct(fv, fieldPath(path, sf.Name), n); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var values []string
+		var nodes []*xmlNode
+
+		switch opts {
+		case "chardata":
+			if n.text != "" {
+				values = append(values, n.text)
+			}
+		case "attr":
+			values = n.attrValues(name)
+		case "":
+			values = n.attrValues(name)
+			nodes = n.childNodes(name)
+		default:
+			// innerxml, comment, etc. are not supported
+			continue
+		}
+
+		if len(values) == 0 && len(nodes) == 0 {
+			continue
+		}
+
+		if err := x.setField(v, fieldPath(path, sf.Name), sf, fv, values, nodes); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setField sets the field fv from the matching attribute values and child
+// nodes. path is the path of the field.
+func (x *XMLLoader) setField(v reflect.Value, path string, sf reflect.StructField, fv reflect.Value, values []string, nodes []*xmlNode) error {
+	for _, n := range nodes {
+		values = append(values, n.text)
+	}
+
+	// flag.Values are set from their text, like scalars
+	if _, ok := fv.Interface().(flag.Value); ok {
+		return x.fieldSet(v, path, sf, values[len(values)-1])
+	}
+
+	switch sf.Type.Kind() {
+	case reflect.Struct:
+		if len(nodes) == 0 {
+			return nil
+		}
+		return x.setStruct(fv, path, nodes[len(nodes)-1])
+	case reflect.Ptr:
+		if sf.Type.Elem().Kind() != reflect.Struct || len(nodes) == 0 {
+			return x.fieldSet(v, path, sf, values[len(values)-1])
+		}
+
+		if fv.IsNil() {
+			fv.Set(reflect.New(sf.Type.Elem()))
+		}
+		return x.setStruct(fv.Elem(), path, nodes[len(nodes)-1])
+	case reflect.Slice:
+		elem := sf.Type.Elem()
+
+		// a single element may wrap the repeated elements
+		if len(nodes) == 1 && isXMLWrapper(nodes[0], elem) {
+			nodes = nodes[0].children
+			values = values[:0]
+			for _, n := range nodes {
+				n.used = true
+				values = append(values, n.text)
+			}
+		}
+
+		switch elem.Kind() {
+		case reflect.Struct:
+			slice := reflect.MakeSlice(sf.Type, 0, len(nodes))
+			for _, n := range nodes {
+				e := reflect.New(elem).Elem()
+				if err := x.setStruct(e, path, n); err != nil {
+					return err
+				}
+				slice = reflect.Append(slice, e)
+			}
+
+			fv.Set(slice)
+			return nil
+		case reflect.String:
+			slice := reflect.MakeSlice(sf.Type, 0, len(values))
+			for _, val := range values {
+				slice = reflect.Append(slice, reflect.ValueOf(val).Convert(elem))
+			}
+
+			fv.Set(slice)
+			return nil
+		}
+
+		return x.fieldSet(v, path, sf, strings.Join(values, ","))
+	}
+
+	return x.fieldSet(v, path, sf, values[len(values)-1])
+}
+
+// fieldSet sets the field sf of struct v in the same way environment
+// variables and flags are set. path is the path of the field.
+func (x *XMLLoader) fieldSet(v reflect.Value, path string, sf reflect.StructField, value string) error {
+	field := structs.New(v.Addr().Interface()).Field(sf.Name)
+	return fieldSet(field, path, value)
+}
+
+// isXMLWrapper reports whether n wraps the repeated elements of a slice with
+// the given element type, i.e: <hosts><host>...</host><host>...</host></hosts>.
+func isXMLWrapper(n *xmlNode, elem reflect.Type) bool {
+	if len(n.children) == 0 || n.text != "" {
+		return false
+	}
+
+	if elem.Kind() != reflect.Struct {
+		return true
+	}
+
+	name := n.children[0].name
+	for _, child := range n.children {
+		if !strings.EqualFold(child.name, name) {
+			return false
+		}
+	}
+
+	// n is a single element of the slice if its children are fields
+	_, ok := lookupField(elem, name, "xml", strings.EqualFold)
+	return !ok
+}
+
+// attrValues returns the values of the attributes with the given name.
+func (n *xmlNode) attrValues(name string) []string {
+	var values []string
+	for _, attr := range n.attrs {
+		if strings.EqualFold(attr.name, name) {
+			attr.used = true
+			values = append(values, attr.value)
+		}
+	}
+
+	return values
+}
+
+// childNodes returns the children elements with the given name.
+func (n *xmlNode) childNodes(name string) []*xmlNode {
+	var nodes []*xmlNode
+	for _, child := range n.children {
+		if strings.EqualFold(child.name, name) {
+			child.used = true
+			nodes = append(nodes, child)
+		}
+	}
+
+	return nodes
+}
+
+// unusedXML returns the path of the first attribute or element that wasn't
+// used to set a field.
+func unusedXML(prefix string, n *xmlNode) string {
+	for _, attr := range n.attrs {
+		if !attr.used {
+			return prefix + attr.name
+		}
+	}
+
+	for _, child := range n.children {
+		if !child.used {
+			return prefix + child.name
+		}
+
+		if key := unusedXML(prefix+child.name+".", child); key != "" {
+			return key
+		}
+	}
+
+	return ""
+}
+
+// parseXML parses the xml document of r and returns its root element.
+func parseXML(r io.Reader) (*xmlNode, error) {
+	d := xml.NewDecoder(r)
+
+	var root *xmlNode
+	var stack []*xmlNode
+	var text strings.Builder
+
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{name: tok.Name.Local}
+			for _, attr := range tok.Attr {
+				// namespace declarations are not config values
+				if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+					continue
+				}
+				n.attrs = append(n.attrs, &xmlAttr{name: attr.Name.Local, value: attr.Value})
+			}
+
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			} else {
+				root = n
+			}
+
+			stack = append(stack, n)
+			text.Reset()
+		case xml.CharData:
+			text.Write(tok)
+		case xml.EndElement:
+			n := stack[len(stack)-1]
+			if len(n.children) == 0 {
+				n.text = strings.TrimSpace(text.String())
+			}
+
+			stack = stack[:len(stack)-1]
+			text.Reset()
+		}
+	}
+
+	if root == nil {
+		return nil, errors.New("no root element")
+	}
+
+	return root, nil
+}